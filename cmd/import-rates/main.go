@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sidmal/ianua/internal/conversion"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultMaxJump = "10"
+)
+
+// import-rates loads courses from CSV or ECB XML rates feed file into courses table
+func main() {
+	file := flag.String("file", "", "path to rates feed file")
+	format := flag.String("format", "", "rates feed format: csv or ecb, it's detected by file extension if empty")
+	maxJump := flag.String("max-jump", defaultMaxJump, "maximal change percent of course against previous course")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	if *file == "" {
+		logger.Fatal("rates feed file isn't specified")
+	}
+
+	if *format == "" {
+		*format = conversion.FeedFormatCSV
+
+		if strings.EqualFold(filepath.Ext(*file), ".xml") {
+			*format = conversion.FeedFormatECB
+		}
+	}
+
+	jump, err := pkg.ParsePercent(*maxJump)
+
+	if err != nil {
+		logger.Fatal("maximal course change percent is invalid", zap.Error(err))
+	}
+
+	feed, err := os.Open(*file)
+
+	if err != nil {
+		logger.Fatal("rates feed opening failed", zap.Error(err))
+	}
+
+	courses, err := conversion.ParseFeed(feed, *format)
+	_ = feed.Close()
+
+	if err != nil {
+		logger.Fatal("rates feed parsing failed", zap.Error(err), zap.String("file", *file))
+	}
+
+	db, err := repository.Connect(context.Background(), &repository.DatabaseOptions{Url: os.Getenv("DATABASE_URL")})
+
+	if err != nil {
+		logger.Fatal("database connection failed", zap.Error(err))
+	}
+
+	defer db.Close()
+
+	if err = repository.LoadCurrencyExponents(context.Background(), db); err != nil {
+		logger.Fatal("currencies loading failed", zap.Error(err))
+	}
+
+	repo := repository.NewRepository(db, &repository.CacheLifetime{}, logger)
+	importer := conversion.NewImporter(repo.GetCourseRepository(), jump)
+
+	if err = importer.Import(context.Background(), courses); err != nil {
+		logger.Fatal("rates import failed", zap.Error(err), zap.String("file", *file))
+	}
+
+	logger.Info("rates imported", zap.Int("count", len(courses)), zap.String("file", *file))
+}
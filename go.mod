@@ -3,9 +3,11 @@ module github.com/sidmal/ianua
 go 1.15
 
 require (
+	github.com/go-playground/validator/v10 v10.4.1
+	github.com/jackc/pgconn v1.5.0
 	github.com/jackc/pgx/v4 v4.6.0
-	github.com/jmoiron/sqlx v1.2.0 // indirect
-	github.com/valyala/fasttemplate v1.2.1 // indirect
-	go.mongodb.org/mongo-driver v1.4.1 // indirect
-	go.uber.org/zap v1.15.0 // indirect
+	github.com/jmoiron/sqlx v1.2.0
+	github.com/valyala/fasttemplate v1.2.1
+	go.mongodb.org/mongo-driver v1.4.1
+	go.uber.org/zap v1.15.0
 )
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"crypto/subtle"
+	"github.com/sidmal/ianua/internal/cache"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	adminCoursesPath = "/admin/courses"
+	adminCachePath   = "/admin/cache/"
+
+	HeaderAuthorization = "Authorization"
+	adminTokenPrefix    = "Bearer "
+)
+
+type coursesRequest struct {
+	Courses []*repository.Course `json:"courses" validate:"required,min=1,dive"`
+}
+
+type cacheResponse struct {
+	Repository string         `json:"repository"`
+	Stats      cache.Stats    `json:"stats"`
+	Entries    []*cache.Entry `json:"entries"`
+}
+
+// adminOnly checks that request contains admin token in authorization header and allows only received methods
+func (m *Api) adminOnly(fn http.HandlerFunc, methods ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := false
+
+		for _, method := range methods {
+			allowed = allowed || r.Method == method
+		}
+
+		if !allowed {
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			m.writeError(w, http.StatusMethodNotAllowed, pkg.ErrorRequestInvalid)
+			return
+		}
+
+		header := r.Header.Get(HeaderAuthorization)
+		token := strings.TrimPrefix(header, adminTokenPrefix)
+
+		if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(m.adminToken)) != 1 {
+			m.writeError(w, http.StatusUnauthorized, pkg.ErrorAdminUnauthorized)
+			return
+		}
+
+		fn(w, r)
+	}
+}
+
+// courses loads courses by POST request and returns course effective at time from "at" query parameter
+// by GET request, current course is returned if time isn't specified
+func (m *Api) courses(w http.ResponseWriter, r *http.Request) {
+	courses := m.repository.GetCourseRepository()
+
+	if r.Method == http.MethodPost {
+		req := new(coursesRequest)
+
+		if !m.decodeRequest(w, r, req) {
+			return
+		}
+
+		for _, course := range req.Courses {
+			if course.Date.IsZero() {
+				m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails("course date is required"))
+				return
+			}
+
+			course.From, course.To = strings.ToUpper(course.From), strings.ToUpper(course.To)
+		}
+
+		if err := courses.CreateCourses(r.Context(), req.Courses); err != nil {
+			m.writeProcessingError(w, err)
+			return
+		}
+
+		m.writeJson(w, http.StatusCreated, req)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to := strings.ToUpper(query.Get("from")), strings.ToUpper(query.Get("to"))
+	at := time.Now()
+
+	if from == "" || to == "" {
+		m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails("currencies are required"))
+		return
+	}
+
+	if value := query.Get("at"); value != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails(err.Error()))
+			return
+		}
+	}
+
+	course, err := courses.GetCourse(r.Context(), from, to, at)
+
+	if err != nil {
+		m.writeProcessingError(w, err)
+		return
+	}
+
+	m.writeJson(w, http.StatusOK, course)
+}
+
+// cache returns cached keys with expiration time and cache statistics of all repositories or of repository
+// from request path by GET request. DELETE request removes cached value by key from "key" query parameter
+// or all values of repository if key isn't specified, removal is broadcast to all instances.
+func (m *Api) cache(w http.ResponseWriter, r *http.Request) {
+	caches := repository.GetCaches(m.repository)
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, adminCachePath), "/")
+
+	if name == "" && r.Method == http.MethodGet {
+		rsp := make([]*cacheResponse, 0, len(caches))
+
+		for _, name := range []string{repository.CacheClient, repository.CacheCourse, repository.CacheProject} {
+			rsp = append(rsp, newCacheResponse(name, caches[name]))
+		}
+
+		m.writeJson(w, http.StatusOK, rsp)
+		return
+	}
+
+	repositoryCache, ok := caches[name]
+
+	if !ok {
+		m.writeError(w, http.StatusNotFound, pkg.ErrorRequestInvalid.SetDetails("repository cache not found"))
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		m.writeJson(w, http.StatusOK, newCacheResponse(name, repositoryCache))
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+
+	if key == "" {
+		repositoryCache.RemoveAllCached()
+		m.logger.Info("repository cache flushed by admin", zap.String("repository", name))
+	} else {
+		repositoryCache.RemoveCachedByKey(key)
+		m.logger.Info("cached value removed by admin", zap.String("repository", name), zap.String("key", key))
+	}
+
+	if err := m.repository.NotifyCacheInvalidation(r.Context(), name, key); err != nil {
+		m.logger.Error("cache invalidation notifying failed", zap.Error(err), zap.String("repository", name))
+		m.writeError(w, http.StatusInternalServerError, pkg.ErrorUnknown)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newCacheResponse(name string, repositoryCache repository.Cache) *cacheResponse {
+	rsp := &cacheResponse{
+		Repository: name,
+		Stats:      repositoryCache.GetCacheStats(),
+		Entries:    repositoryCache.GetAllCached(),
+	}
+	return rsp
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/payment"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	callbackPath      = "/callback/"
+	paymentPath       = "/payment"
+	paymentStatusPath = "/payment/status"
+	accountCheckPath  = "/account/check"
+
+	maxRequestBodyLength = 1 << 20
+)
+
+var (
+	errorStatuses = map[pkg.Error]int{
+		*pkg.ErrorMerchantNotFound:               http.StatusUnauthorized,
+		*pkg.ErrorUnauthorized:                   http.StatusUnauthorized,
+		*pkg.ErrorRequestSignatureInvalid:        http.StatusUnauthorized,
+		*pkg.ErrorRequestTimestampInvalid:        http.StatusUnauthorized,
+		*pkg.ErrorRequestNonceReused:             http.StatusUnauthorized,
+		*pkg.ErrorRequestInvalid:                 http.StatusBadRequest,
+		*pkg.ErrorAccountInvalid:                 http.StatusUnprocessableEntity,
+		*pkg.ErrorServiceNotFound:                http.StatusNotFound,
+		*pkg.ErrorServiceInactive:                http.StatusUnprocessableEntity,
+		*pkg.ErrorProviderNotFound:               http.StatusNotFound,
+		*pkg.ErrorProviderInactive:               http.StatusUnprocessableEntity,
+		*pkg.ErrorCourseNotFound:                 http.StatusUnprocessableEntity,
+		*pkg.ErrorGatewayNotFound:                http.StatusUnprocessableEntity,
+		*pkg.ErrorTransactionNotFound:            http.StatusNotFound,
+		*pkg.ErrorTransactionAlreadyFinished:     http.StatusConflict,
+		*pkg.ErrorTransactionConflict:            http.StatusConflict,
+		*pkg.ErrorTransactionTransitionForbidden: http.StatusConflict,
+		*pkg.ErrorTransactionVersionConflict:     http.StatusConflict,
+		*pkg.ErrorInsufficientFunds:              http.StatusPaymentRequired,
+		*pkg.ErrorAmountInvalid:                  http.StatusBadRequest,
+		*pkg.ErrorAdminUnauthorized:              http.StatusUnauthorized,
+		*pkg.ErrorAmountOutOfLimits:              http.StatusUnprocessableEntity,
+		*pkg.ErrorAmountVelocityExceeded:         http.StatusUnprocessableEntity,
+	}
+)
+
+type Api struct {
+	repository repository.Interface
+	gateways   gateway.Gateways
+	processor  *payment.Processor
+	validate   *validator.Validate
+	clockSkew  time.Duration
+	adminToken string
+	logger     *zap.Logger
+	mux        *http.ServeMux
+}
+
+type clientHandlerFunc func(w http.ResponseWriter, r *http.Request, client *repository.Client)
+
+func NewApi(
+	repository repository.Interface,
+	gateways gateway.Gateways,
+	processor *payment.Processor,
+	clockSkew time.Duration,
+	adminToken string,
+	logger *zap.Logger,
+) *Api {
+	api := &Api{
+		repository: repository,
+		gateways:   gateways,
+		processor:  processor,
+		validate:   validator.New(),
+		clockSkew:  clockSkew,
+		adminToken: adminToken,
+		logger:     logger,
+		mux:        http.NewServeMux(),
+	}
+
+	api.mux.HandleFunc(healthPath, api.health)
+	api.mux.HandleFunc(callbackPath, api.callback)
+	api.mux.HandleFunc(paymentPath, api.authenticate(api.createPayment))
+	api.mux.HandleFunc(paymentStatusPath, api.authenticate(api.paymentStatus))
+	api.mux.HandleFunc(accountCheckPath, api.authenticate(api.checkAccount))
+
+	// admin endpoints are disabled when admin token isn't set
+	if adminToken != "" {
+		api.mux.HandleFunc(adminCoursesPath, api.adminOnly(api.courses, http.MethodGet, http.MethodPost))
+		api.mux.HandleFunc(adminCachePath, api.adminOnly(api.cache, http.MethodGet, http.MethodDelete))
+	}
+
+	return api
+}
+
+func (m *Api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// decodeRequest decodes JSON request body to request structure and validates it by structure tags
+func (m *Api) decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyLength))
+
+	if err := decoder.Decode(req); err != nil {
+		m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails(err.Error()))
+		return false
+	}
+
+	if err := m.validate.Struct(req); err != nil {
+		m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails(err.Error()))
+		return false
+	}
+
+	return true
+}
+
+func (m *Api) writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		m.logger.Error("response writing failed", zap.Error(err))
+	}
+}
+
+func (m *Api) writeError(w http.ResponseWriter, status int, err *pkg.Error) {
+	m.writeJson(w, status, err)
+}
+
+// writeProcessingError writes error returned by request processing with http status code matched to error
+func (m *Api) writeProcessingError(w http.ResponseWriter, err error) {
+	e, ok := err.(*pkg.Error)
+
+	if !ok {
+		m.logger.Error("request processing failed", zap.Error(err))
+		m.writeError(w, http.StatusInternalServerError, pkg.ErrorUnknown)
+		return
+	}
+
+	status, ok := errorStatuses[pkg.Error{Code: e.Code, Message: e.Message}]
+
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	m.writeError(w, status, e)
+}
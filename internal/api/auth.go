@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderClientId  = "X-Client-Id"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+
+	maxNonceLength = 64
+)
+
+// authenticate finds client by identifier from request headers and verifies request signature.
+// Signature is HMAC-SHA256 with client secret key encoded to hex, it's calculated by canonical string which
+// contains request method, path, timestamp, nonce and body separated by new line symbol.
+func (m *Api) authenticate(fn clientHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			m.writeError(w, http.StatusMethodNotAllowed, pkg.ErrorRequestInvalid)
+			return
+		}
+
+		clientId := r.Header.Get(HeaderClientId)
+		timestamp := r.Header.Get(HeaderTimestamp)
+		nonce := r.Header.Get(HeaderNonce)
+		sign := r.Header.Get(HeaderSignature)
+
+		if clientId == "" || timestamp == "" || nonce == "" || sign == "" {
+			m.writeError(w, http.StatusUnauthorized, pkg.ErrorUnauthorized)
+			return
+		}
+
+		if len(nonce) > maxNonceLength {
+			m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails("nonce is too long"))
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+
+		if err != nil || absDuration(time.Since(time.Unix(unix, 0))) > m.clockSkew {
+			m.writeError(w, http.StatusUnauthorized, pkg.ErrorRequestTimestampInvalid)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyLength))
+
+		if err != nil {
+			m.writeError(w, http.StatusBadRequest, pkg.ErrorRequestInvalid.SetDetails(err.Error()))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		client, err := m.repository.GetClientRepository().GetClient(r.Context(), clientId)
+
+		if err != nil {
+			m.writeProcessingError(w, err)
+			return
+		}
+
+		expected := requestSignature(client, r, timestamp, nonce, body)
+
+		if !hmac.Equal([]byte(strings.ToLower(sign)), []byte(expected)) {
+			m.writeError(w, http.StatusUnauthorized, pkg.ErrorRequestSignatureInvalid)
+			return
+		}
+
+		added, err := m.repository.GetNonceRepository().Add(r.Context(), client.Id, nonce, time.Now().Add(2*m.clockSkew))
+
+		if err != nil {
+			m.writeProcessingError(w, err)
+			return
+		}
+
+		if !added {
+			m.writeError(w, http.StatusUnauthorized, pkg.ErrorRequestNonceReused)
+			return
+		}
+
+		fn(w, r, client)
+	}
+}
+
+// RunNonceCleaner removes expired requests nonces by interval until context is done
+func (m *Api) RunNonceCleaner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = m.repository.GetNonceRepository().RemoveExpired(ctx, time.Now())
+		}
+	}
+}
+
+func requestSignature(client *repository.Client, r *http.Request, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(client.SecretKey))
+	mac.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// repositoryStub is a repository which provides client and nonce repositories only
+type repositoryStub struct {
+	repository.Interface
+	clients *clientRepositoryStub
+	nonces  *nonceRepositoryStub
+}
+
+type clientRepositoryStub struct {
+	repository.MerchantRepositoryInterface
+	client *repository.Client
+}
+
+// nonceRepositoryStub keeps used nonces in memory
+type nonceRepositoryStub struct {
+	used map[string]bool
+}
+
+func (m *repositoryStub) GetClientRepository() repository.MerchantRepositoryInterface {
+	return m.clients
+}
+
+func (m *repositoryStub) GetNonceRepository() repository.NonceRepositoryInterface {
+	return m.nonces
+}
+
+func (m *clientRepositoryStub) GetClient(_ context.Context, uuid string) (*repository.Client, error) {
+	if m.client.Uuid != uuid {
+		return nil, pkg.ErrorMerchantNotFound
+	}
+
+	return m.client, nil
+}
+
+func (m *nonceRepositoryStub) Add(_ context.Context, _ uint64, nonce string, _ time.Time) (bool, error) {
+	if m.used[nonce] {
+		return false, nil
+	}
+
+	m.used[nonce] = true
+	return true, nil
+}
+
+func (m *nonceRepositoryStub) RemoveExpired(context.Context, time.Time) error {
+	return nil
+}
+
+func TestApi_authenticate(t *testing.T) {
+	client := &repository.Client{Model: repository.Model{Uuid: "client"}, SecretKey: "secret"}
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name      string
+		method    string
+		clientId  string
+		timestamp int64
+		nonce     string
+		secret    string
+		status    int
+		err       *pkg.Error
+	}{
+		{"authenticated", http.MethodPost, "client", now, "n-1", "secret", http.StatusOK, nil},
+		{
+			"not post request",
+			http.MethodGet, "client", now, "n-1", "secret",
+			http.StatusMethodNotAllowed, pkg.ErrorRequestInvalid,
+		},
+		{"headers missing", http.MethodPost, "client", now, "", "secret", http.StatusUnauthorized, pkg.ErrorUnauthorized},
+		{
+			"timestamp outside clock skew in past",
+			http.MethodPost, "client", now - 120, "n-1", "secret",
+			http.StatusUnauthorized, pkg.ErrorRequestTimestampInvalid,
+		},
+		{
+			"timestamp outside clock skew in future",
+			http.MethodPost, "client", now + 120, "n-1", "secret",
+			http.StatusUnauthorized, pkg.ErrorRequestTimestampInvalid,
+		},
+		{
+			"nonce too long",
+			http.MethodPost, "client", now, strings.Repeat("n", maxNonceLength+1), "secret",
+			http.StatusBadRequest, pkg.ErrorRequestInvalid,
+		},
+		{
+			"nonce reused",
+			http.MethodPost, "client", now, "used", "secret",
+			http.StatusUnauthorized, pkg.ErrorRequestNonceReused,
+		},
+		{
+			"signature invalid",
+			http.MethodPost, "client", now, "n-1", "other",
+			http.StatusUnauthorized, pkg.ErrorRequestSignatureInvalid,
+		},
+		{
+			"client unknown",
+			http.MethodPost, "other", now, "n-1", "secret",
+			http.StatusUnauthorized, pkg.ErrorMerchantNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repositoryStub{
+				clients: &clientRepositoryStub{client: client},
+				nonces:  &nonceRepositoryStub{used: map[string]bool{"used": true}},
+			}
+			api := NewApi(repo, nil, nil, time.Minute, "", zap.NewNop())
+			handler := api.authenticate(func(w http.ResponseWriter, r *http.Request, client *repository.Client) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			body := `{"order_id":"1"}`
+			timestamp := strconv.FormatInt(tt.timestamp, 10)
+			req := httptest.NewRequest(tt.method, paymentPath, strings.NewReader(body))
+			req.Header.Set(HeaderClientId, tt.clientId)
+			req.Header.Set(HeaderTimestamp, timestamp)
+			req.Header.Set(HeaderNonce, tt.nonce)
+			req.Header.Set(
+				HeaderSignature,
+				requestSignature(&repository.Client{SecretKey: tt.secret}, req, timestamp, tt.nonce, []byte(body)),
+			)
+
+			rsp := httptest.NewRecorder()
+			handler(rsp, req)
+
+			if rsp.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rsp.Code, rsp.Body)
+			}
+
+			if tt.err == nil {
+				return
+			}
+
+			e := &pkg.Error{}
+
+			if err := json.Unmarshal(rsp.Body.Bytes(), e); err != nil || e.Code != tt.err.Code {
+				t.Errorf("expected error %s, got %s", tt.err.Code, rsp.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Maximal count of attempts to change transaction status by callback when transaction is changed concurrently
+	maxCallbackAttempts = 3
+)
+
+// callback receives notification about payment result from provider which handler is specified in request path
+// and finishes transaction. Repeated notifications with same result are acknowledged without changes.
+func (m *Api) callback(w http.ResponseWriter, r *http.Request) {
+	handler := strings.Trim(strings.TrimPrefix(r.URL.Path, callbackPath), "/")
+	gw, err := m.gateways.Get(handler)
+
+	if err != nil {
+		m.writeError(w, http.StatusNotFound, pkg.ErrorGatewayNotFound)
+		return
+	}
+
+	result, err := gw.ParseCallback(r)
+
+	if err != nil {
+		m.logger.Warn("callback parsing failed", zap.String("handler", handler), zap.Error(err))
+
+		if err == gateway.ErrorCallbackSignatureInvalid {
+			m.writeError(w, http.StatusForbidden, pkg.ErrorCallbackSignatureInvalid)
+			return
+		}
+
+		m.writeError(w, http.StatusBadRequest, pkg.ErrorCallbackInvalid)
+		return
+	}
+
+	if result.ProviderTxnId == "" {
+		m.writeError(w, http.StatusBadRequest, pkg.ErrorCallbackInvalid)
+		return
+	}
+
+	ctx := r.Context()
+	transactions := m.repository.GetTransactionRepository()
+	var txn *repository.Transaction
+
+	for attempt := 0; attempt < maxCallbackAttempts; attempt++ {
+		txn, err = transactions.GetTransactionByProviderTxnId(ctx, handler, result.ProviderTxnId)
+
+		if err != nil {
+			m.writeError(w, http.StatusInternalServerError, pkg.ErrorUnknown)
+			return
+		}
+
+		if txn == nil {
+			m.writeError(w, http.StatusNotFound, pkg.ErrorTransactionNotFound)
+			return
+		}
+
+		switch {
+		case txn.Status == result.Status:
+			err = nil
+		case result.Status == repository.TransactionStatusCompleted:
+			err = transactions.Complete(ctx, txn, result.ProviderTxnId)
+		case result.Status == repository.TransactionStatusRejected:
+			err = transactions.Reject(ctx, txn, result.ProviderTxnId, result.RejectReason)
+		case result.Status == repository.TransactionStatusManualReview:
+			err = transactions.Transit(ctx, txn, repository.TransactionStatusManualReview, result.RejectReason)
+		}
+
+		if err != pkg.ErrorTransactionVersionConflict {
+			break
+		}
+	}
+
+	if err == pkg.ErrorTransactionAlreadyFinished || err == pkg.ErrorTransactionTransitionForbidden {
+		m.logger.Error(
+			"callback result conflicts with transaction status",
+			zap.String("handler", handler),
+			zap.String("provider_txn_id", result.ProviderTxnId),
+			zap.String("transaction_status", txn.Status),
+			zap.String("callback_status", result.Status),
+		)
+		m.writeError(w, http.StatusConflict, err.(*pkg.Error))
+		return
+	}
+
+	if err != nil {
+		m.writeProcessingError(w, err)
+		return
+	}
+
+	if gw.Callback.ResponseContentType != "" {
+		w.Header().Set("Content-Type", gw.Callback.ResponseContentType)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(gw.Callback.ResponseBody))
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	healthPath = "/health"
+
+	healthStatusOk          = "ok"
+	healthStatusUnavailable = "unavailable"
+
+	// Maximal time of database availability check
+	healthCheckTimeout = 2 * time.Second
+)
+
+type healthResponse struct {
+	Status   string          `json:"status"`
+	Database *databaseHealth `json:"database"`
+}
+
+type databaseHealth struct {
+	Status          string `json:"status"`
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	Idle            int    `json:"idle"`
+	WaitCount       int64  `json:"wait_count"`
+}
+
+// health checks database availability and returns state of database connections pool,
+// service is unavailable if database doesn't respond
+func (m *Api) health(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	stats := m.repository.GetDatabaseStats()
+	rsp := &healthResponse{
+		Status: healthStatusOk,
+		Database: &databaseHealth{
+			Status:          healthStatusOk,
+			OpenConnections: stats.OpenConnections,
+			InUse:           stats.InUse,
+			Idle:            stats.Idle,
+			WaitCount:       stats.WaitCount,
+		},
+	}
+	status := http.StatusOK
+
+	if err := m.repository.Ping(ctx); err != nil {
+		m.logger.Error("database health check failed", zap.Error(err))
+		rsp.Status, rsp.Database.Status = healthStatusUnavailable, healthStatusUnavailable
+		status = http.StatusServiceUnavailable
+	}
+
+	m.writeJson(w, status, rsp)
+}
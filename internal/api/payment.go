@@ -0,0 +1,82 @@
+package api
+
+import (
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"net/http"
+)
+
+func (m *Api) createPayment(w http.ResponseWriter, r *http.Request, client *repository.Client) {
+	req := new(pkg.PaymentRequest)
+
+	if !m.decodeRequest(w, r, req) {
+		return
+	}
+
+	txn, created, err := m.processor.Create(r.Context(), client, req)
+
+	if err != nil {
+		m.writeProcessingError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+
+	if created {
+		status = http.StatusCreated
+	}
+
+	m.writeJson(w, status, transactionResponse(txn))
+}
+
+func (m *Api) paymentStatus(w http.ResponseWriter, r *http.Request, client *repository.Client) {
+	req := new(pkg.StatusRequest)
+
+	if !m.decodeRequest(w, r, req) {
+		return
+	}
+
+	txn, err := m.processor.Status(r.Context(), client, req)
+
+	if err != nil {
+		m.writeProcessingError(w, err)
+		return
+	}
+
+	m.writeJson(w, http.StatusOK, transactionResponse(txn))
+}
+
+func (m *Api) checkAccount(w http.ResponseWriter, r *http.Request, _ *repository.Client) {
+	req := new(pkg.BaseRequest)
+
+	if !m.decodeRequest(w, r, req) {
+		return
+	}
+
+	rsp, err := m.processor.CheckAccount(r.Context(), req)
+
+	if err != nil {
+		m.writeProcessingError(w, err)
+		return
+	}
+
+	m.writeJson(w, http.StatusOK, rsp)
+}
+
+func transactionResponse(txn *repository.Transaction) *pkg.TransactionResponse {
+	rsp := &pkg.TransactionResponse{
+		Id:           txn.Uuid,
+		Account:      txn.Account,
+		Amount:       txn.IncomeAmount.Decimal(txn.IncomeCurrency),
+		Currency:     txn.IncomeCurrency,
+		Status:       txn.Status,
+		RejectReason: txn.GatewayRejectReason,
+		CreatedAt:    txn.CreatedAt,
+	}
+
+	if txn.ClientTxnId != nil {
+		rsp.OrderId = *txn.ClientTxnId
+	}
+
+	return rsp
+}
@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultLoadTimeout = 10 * time.Second
+)
+
+// Loader loads value which isn't found in cache
+type Loader func(ctx context.Context) (interface{}, error)
+
+type Options struct {
+	// The lifetime of cached values, values aren't cached if it's zero
+	Lifetime time.Duration
+	// The maximal count of cached values, the least recently used value is evicted when it's exceeded,
+	// count isn't limited if it's zero
+	MaxSize int
+	// The interval of eviction of expired values in background, it's disabled if it's zero
+	EvictionInterval time.Duration
+	// The maximal time of value loading, default timeout is used if it's zero
+	LoadTimeout time.Duration
+}
+
+// Entry is a cached value with its key and expiration time
+type Entry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"-"`
+	Expire time.Time   `json:"expire"`
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// Cache is a concurrent safe cache with values lifetime and LRU eviction. Concurrent loads of same missed key
+// are executed once and their callers share loading result.
+type Cache struct {
+	opts  *Options
+	mx    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+	calls map[string]*call
+	done  chan struct{}
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// call is an in-flight loading of value by key
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+	// The flag that key was removed from cache while value was loading, so loaded value mustn't be cached
+	stale bool
+}
+
+func New(opts *Options) *Cache {
+	cache := &Cache{
+		opts:  opts,
+		items: make(map[string]*list.Element),
+		lru:   list.New(),
+		calls: make(map[string]*call),
+		done:  make(chan struct{}),
+	}
+
+	if opts.Lifetime > 0 && opts.EvictionInterval > 0 {
+		go cache.runEviction()
+	}
+
+	return cache
+}
+
+// Get returns not expired value by key
+func (m *Cache) Get(key string) (interface{}, bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	element, ok := m.items[key]
+
+	if !ok || element.Value.(*Entry).Expire.Before(time.Now()) {
+		atomic.AddUint64(&m.misses, 1)
+		return nil, false
+	}
+
+	atomic.AddUint64(&m.hits, 1)
+	m.lru.MoveToFront(element)
+
+	return element.Value.(*Entry).Value, true
+}
+
+// Set saves value by key for cache lifetime
+func (m *Cache) Set(key string, value interface{}) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.set(key, value)
+}
+
+// GetOrLoad returns cached value by key or loads it by loader and caches it, loading errors aren't cached.
+// Concurrent callers share single loading which isn't bound to context of any caller, each caller waits for
+// loading result until its context is done. Value isn't cached if key was removed from cache during loading.
+func (m *Cache) GetOrLoad(ctx context.Context, key string, loader Loader) (interface{}, error) {
+	if value, ok := m.Get(key); ok {
+		return value, nil
+	}
+
+	m.mx.Lock()
+	c, ok := m.calls[key]
+
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		m.calls[key] = c
+		go m.load(key, c, loader)
+	}
+
+	m.mx.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Cache) Remove(key string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		m.removeElement(element)
+	}
+
+	if c, ok := m.calls[key]; ok {
+		c.stale = true
+		delete(m.calls, key)
+	}
+}
+
+func (m *Cache) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.items = make(map[string]*list.Element)
+	m.lru.Init()
+
+	for _, c := range m.calls {
+		c.stale = true
+	}
+
+	m.calls = make(map[string]*call)
+}
+
+// Entries returns all cached entries from the most recently used
+func (m *Cache) Entries() []*Entry {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	entries := make([]*Entry, 0, m.lru.Len())
+
+	for element := m.lru.Front(); element != nil; element = element.Next() {
+		entry := *element.Value.(*Entry)
+		entries = append(entries, &entry)
+	}
+
+	return entries
+}
+
+func (m *Cache) Stats() Stats {
+	m.mx.Lock()
+	size := m.lru.Len()
+	m.mx.Unlock()
+
+	stats := Stats{
+		Hits:      atomic.LoadUint64(&m.hits),
+		Misses:    atomic.LoadUint64(&m.misses),
+		Evictions: atomic.LoadUint64(&m.evictions),
+		Size:      size,
+	}
+	return stats
+}
+
+// Close stops background eviction
+func (m *Cache) Close() {
+	select {
+	case <-m.done:
+	default:
+		close(m.done)
+	}
+}
+
+func (m *Cache) runEviction() {
+	ticker := time.NewTicker(m.opts.EvictionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.evictExpired()
+		}
+	}
+}
+
+func (m *Cache) evictExpired() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	current := time.Now()
+
+	for element := m.lru.Back(); element != nil; {
+		prev := element.Prev()
+
+		if element.Value.(*Entry).Expire.Before(current) {
+			m.removeElement(element)
+			atomic.AddUint64(&m.evictions, 1)
+		}
+
+		element = prev
+	}
+}
+
+// load loads value by loader with timeout and caches it if key wasn't removed during loading, loader panic
+// is returned to callers as error
+func (m *Cache) load(key string, c *call, loader Loader) {
+	timeout := m.opts.LoadTimeout
+
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer func() {
+		cancel()
+
+		if r := recover(); r != nil {
+			c.value, c.err = nil, fmt.Errorf("cache loader panicked: %v", r)
+		}
+
+		m.mx.Lock()
+
+		if !c.stale {
+			if c.err == nil {
+				m.set(key, c.value)
+			}
+
+			delete(m.calls, key)
+		}
+
+		m.mx.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = loader(ctx)
+}
+
+// set saves value by key for cache lifetime, cache must be locked by caller
+func (m *Cache) set(key string, value interface{}) {
+	if m.opts.Lifetime <= 0 {
+		return
+	}
+
+	entry := &Entry{
+		Key:    key,
+		Value:  value,
+		Expire: time.Now().Add(m.opts.Lifetime),
+	}
+
+	if element, ok := m.items[key]; ok {
+		element.Value = entry
+		m.lru.MoveToFront(element)
+		return
+	}
+
+	m.items[key] = m.lru.PushFront(entry)
+
+	for m.opts.MaxSize > 0 && m.lru.Len() > m.opts.MaxSize {
+		m.removeElement(m.lru.Back())
+		atomic.AddUint64(&m.evictions, 1)
+	}
+}
+
+func (m *Cache) removeElement(element *list.Element) {
+	m.lru.Remove(element)
+	delete(m.items, element.Value.(*Entry).Key)
+}
@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		keys     []string
+		expected map[string]bool
+	}{
+		{
+			name:     "cached",
+			opts:     &Options{Lifetime: time.Minute},
+			keys:     []string{"a", "b"},
+			expected: map[string]bool{"a": true, "b": true, "c": false},
+		},
+		{
+			name:     "caching disabled by zero lifetime",
+			opts:     &Options{},
+			keys:     []string{"a"},
+			expected: map[string]bool{"a": false},
+		},
+		{
+			name:     "least recently used evicted",
+			opts:     &Options{Lifetime: time.Minute, MaxSize: 2},
+			keys:     []string{"a", "b", "c"},
+			expected: map[string]bool{"a": false, "b": true, "c": true},
+		},
+		{
+			name:     "expired",
+			opts:     &Options{Lifetime: time.Nanosecond},
+			keys:     []string{"a"},
+			expected: map[string]bool{"a": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(tt.opts)
+			defer cache.Close()
+
+			for _, key := range tt.keys {
+				cache.Set(key, key)
+			}
+
+			time.Sleep(time.Millisecond)
+
+			for key, cached := range tt.expected {
+				value, ok := cache.Get(key)
+
+				if ok != cached {
+					t.Errorf("expected key %q cached %v, got %v", key, cached, ok)
+				}
+
+				if ok && value != key {
+					t.Errorf("expected value %q, got %v", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	tests := []struct {
+		name   string
+		loader Loader
+		value  interface{}
+		err    string
+		cached bool
+	}{
+		{
+			name:   "loaded value cached",
+			loader: func(ctx context.Context) (interface{}, error) { return "value", nil },
+			value:  "value",
+			cached: true,
+		},
+		{
+			name:   "loading error not cached",
+			loader: func(ctx context.Context) (interface{}, error) { return nil, errLoad },
+			err:    errLoad.Error(),
+		},
+		{
+			name:   "loader panic returned as error",
+			loader: func(ctx context.Context) (interface{}, error) { panic("boom") },
+			err:    "cache loader panicked: boom",
+		},
+		{
+			name: "loader context detached from caller and bounded by timeout",
+			loader: func(ctx context.Context) (interface{}, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("loader context has no deadline")
+				}
+
+				return "value", ctx.Err()
+			},
+			value:  "value",
+			cached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(&Options{Lifetime: time.Minute})
+			defer cache.Close()
+
+			value, err := cache.GetOrLoad(context.Background(), "key", tt.loader)
+
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if value != tt.value {
+				t.Errorf("expected value %v, got %v", tt.value, value)
+			}
+
+			if _, ok := cache.Get("key"); ok != tt.cached {
+				t.Errorf("expected cached %v, got %v", tt.cached, ok)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadShared(t *testing.T) {
+	cache := New(&Options{Lifetime: time.Minute})
+	defer cache.Close()
+
+	release := make(chan struct{})
+	loads := int32(0)
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan interface{}, 10)
+
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			value, _ := cache.GetOrLoad(context.Background(), "key", loader)
+			results <- value
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+
+	for value := range results {
+		if value != "value" {
+			t.Errorf("expected shared value, got %v", value)
+		}
+	}
+}
+
+func TestCache_GetOrLoadInvalidated(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(cache *Cache)
+	}{
+		{"removed", func(cache *Cache) { cache.Remove("key") }},
+		{"cleared", func(cache *Cache) { cache.Clear() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(&Options{Lifetime: time.Minute})
+			defer cache.Close()
+
+			started, release := make(chan struct{}), make(chan struct{})
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				_, _ = cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+					close(started)
+					<-release
+					return "stale", nil
+				})
+			}()
+
+			<-started
+			tt.invalidate(cache)
+
+			// loading started after invalidation isn't joined to stale loading
+			value, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+				return "fresh", nil
+			})
+
+			if err != nil || value != "fresh" {
+				t.Fatalf("expected fresh value, got %v, %v", value, err)
+			}
+
+			close(release)
+			<-done
+
+			if value, _ := cache.Get("key"); value != "fresh" {
+				t.Errorf("expected fresh value cached, got %v", value)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadCallerCanceled(t *testing.T) {
+	cache := New(&Options{Lifetime: time.Minute})
+	defer cache.Close()
+
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cache.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		<-release
+		return "value", ctx.Err()
+	})
+
+	if err != context.Canceled {
+		t.Fatalf("expected context canceled error, got %v", err)
+	}
+
+	close(release)
+
+	// loading isn't canceled by caller, so its result is shared with the next caller
+	value, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		return "other", nil
+	})
+
+	if err != nil || value != "value" {
+		t.Errorf("expected value of first loading, got %v, %v", value, err)
+	}
+}
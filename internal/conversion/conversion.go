@@ -0,0 +1,158 @@
+package conversion
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+)
+
+const (
+	ConversionIncomeToOutcome     = "income_to_outcome"
+	ConversionIncomeToAccounting  = "income_to_accounting"
+	ConversionOutcomeToAccounting = "outcome_to_accounting"
+)
+
+type courseGetter func(ctx context.Context, from, to string) (*repository.Course, error)
+
+// Converter resolves conversion rates between currencies and fills transaction amounts in income, outcome
+// and accounting currencies
+type Converter struct {
+	courses repository.CourseRepositoryInterface
+	// The currency through which cross rates are resolved when direct and inverse rates not found
+	baseCurrency string
+	// The system accounting currency
+	accountingCurrency string
+}
+
+func NewConverter(courses repository.CourseRepositoryInterface, baseCurrency, accountingCurrency string) *Converter {
+	converter := &Converter{
+		courses:            courses,
+		baseCurrency:       baseCurrency,
+		accountingCurrency: accountingCurrency,
+	}
+	return converter
+}
+
+// Apply converts transaction income amount to outcome and accounting currencies by current rates and saves
+// copies of used courses to transaction. Client's rate markup is applied to rate of conversion to outcome
+// currency only, accounting amounts are converted by market rates.
+func (m *Converter) Apply(ctx context.Context, client *repository.Client, txn *repository.Transaction) error {
+	snapshots := make(repository.CourseSnapshots, 0)
+	conversions := []struct {
+		name     string
+		from, to string
+		rate     *pkg.Rate
+	}{
+		{ConversionIncomeToOutcome, txn.IncomeCurrency, txn.OutcomeCurrency, &txn.IncomeToOutcomeRate},
+		{ConversionIncomeToAccounting, txn.IncomeCurrency, m.accountingCurrency, &txn.IncomeToAccountingRate},
+		{ConversionOutcomeToAccounting, txn.OutcomeCurrency, m.accountingCurrency, &txn.OutcomeToAccountingRate},
+	}
+
+	for _, conversion := range conversions {
+		rate, used, err := m.resolve(ctx, conversion.from, conversion.to, m.courses.GetCurrentCourse)
+
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range used {
+			snapshot.Conversion = conversion.name
+		}
+
+		*conversion.rate = rate
+		snapshots = append(snapshots, used...)
+	}
+
+	rate, err := txn.IncomeToOutcomeRate.WithMarkup(client.RateMarkup)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	txn.IncomeToOutcomeRate = rate
+	outcome, err := txn.Income().Convert(txn.IncomeToOutcomeRate, txn.OutcomeCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	accounting, err := txn.Income().Convert(txn.IncomeToAccountingRate, m.accountingCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	txn.OutcomeAmount = outcome.Amount
+	txn.AccountingAmount = accounting.Amount
+	txn.AccountingCurrency = m.accountingCurrency
+	txn.CourseSnapshots = snapshots
+
+	return nil
+}
+
+// resolve returns rate of conversion from currency to currency and courses which were used to calculate it
+func (m *Converter) resolve(
+	ctx context.Context,
+	from, to string,
+	getter courseGetter,
+) (pkg.Rate, []*repository.CourseSnapshot, error) {
+	rate, snapshot, err := m.getDirectOrInverseRate(ctx, from, to, getter)
+
+	if err != pkg.ErrorCourseNotFound || from == m.baseCurrency || to == m.baseCurrency {
+		return rate, snapshot, err
+	}
+
+	fromBase, fromSnapshot, err := m.getDirectOrInverseRate(ctx, from, m.baseCurrency, getter)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	baseTo, toSnapshot, err := m.getDirectOrInverseRate(ctx, m.baseCurrency, to, getter)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rate, err = fromBase.Mul(baseTo)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return rate, append(fromSnapshot, toSnapshot...), nil
+}
+
+func (m *Converter) getDirectOrInverseRate(
+	ctx context.Context,
+	from, to string,
+	getter courseGetter,
+) (pkg.Rate, []*repository.CourseSnapshot, error) {
+	if from == to {
+		return pkg.RateOne, nil, nil
+	}
+
+	course, err := getter(ctx, from, to)
+
+	if err == nil {
+		return course.Value, []*repository.CourseSnapshot{{Course: course}}, nil
+	}
+
+	if err != pkg.ErrorCourseNotFound {
+		return 0, nil, err
+	}
+
+	course, err = getter(ctx, to, from)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rate, err := course.Value.Inverse()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return rate, []*repository.CourseSnapshot{{Inverse: true, Course: course}}, nil
+}
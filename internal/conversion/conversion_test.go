@@ -0,0 +1,184 @@
+package conversion
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"testing"
+	"time"
+)
+
+// courseRepositoryStub returns courses by currencies pair from memory
+type courseRepositoryStub struct {
+	repository.CourseRepositoryInterface
+	courses map[string]pkg.Rate
+}
+
+func (m *courseRepositoryStub) GetCurrentCourse(_ context.Context, from, to string) (*repository.Course, error) {
+	return m.GetCourse(context.Background(), from, to, time.Now())
+}
+
+func (m *courseRepositoryStub) GetCourse(_ context.Context, from, to string, _ time.Time) (*repository.Course, error) {
+	value, ok := m.courses[from+to]
+
+	if !ok {
+		return nil, pkg.ErrorCourseNotFound
+	}
+
+	return &repository.Course{From: from, To: to, Value: value}, nil
+}
+
+func TestConverter_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		courses    map[string]string
+		markup     pkg.Percent
+		from, to   string
+		amount     pkg.Amount
+		outcome    pkg.Amount
+		accounting pkg.Amount
+		snapshots  int
+		inverse    int
+		err        error
+	}{
+		{
+			name:       "direct rate",
+			courses:    map[string]string{"USDEUR": "0.9", "EURUSD": "1.1"},
+			from:       "USD",
+			to:         "EUR",
+			amount:     1000,
+			outcome:    900,
+			accounting: 1000,
+			snapshots:  2,
+		},
+		{
+			name:       "inverse rate",
+			courses:    map[string]string{"EURUSD": "2"},
+			from:       "USD",
+			to:         "EUR",
+			amount:     1000,
+			outcome:    500,
+			accounting: 1000,
+			snapshots:  2,
+			inverse:    1,
+		},
+		{
+			name:       "cross rate through base currency",
+			courses:    map[string]string{"RUBUSD": "0.01", "USDKZT": "5"},
+			from:       "RUB",
+			to:         "KZT",
+			amount:     10000,
+			outcome:    500,
+			accounting: 100,
+			snapshots:  4,
+			inverse:    1,
+		},
+		{
+			name:       "markup applied to outcome rate only",
+			courses:    map[string]string{"EURUSD": "2"},
+			markup:     pkg.Percent(pkg.RateMultiplier),
+			from:       "EUR",
+			to:         "USD",
+			amount:     1000,
+			outcome:    1980,
+			accounting: 2000,
+			snapshots:  2,
+		},
+		{
+			name:    "rate not found",
+			courses: map[string]string{"USDEUR": "0.9"},
+			from:    "RUB",
+			to:      "EUR",
+			amount:  1000,
+			err:     pkg.ErrorCourseNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses := &courseRepositoryStub{courses: make(map[string]pkg.Rate)}
+
+			for pair, value := range tt.courses {
+				rate, err := pkg.ParseRate(value)
+
+				if err != nil {
+					t.Fatalf("rate parsing failed: %v", err)
+				}
+
+				courses.courses[pair] = rate
+			}
+
+			converter := NewConverter(courses, "USD", "USD")
+			txn := &repository.Transaction{IncomeAmount: tt.amount, IncomeCurrency: tt.from, OutcomeCurrency: tt.to}
+			err := converter.Apply(context.Background(), &repository.Client{RateMarkup: tt.markup}, txn)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if txn.OutcomeAmount != tt.outcome || txn.AccountingAmount != tt.accounting {
+				t.Errorf(
+					"expected outcome %d and accounting %d, got %d and %d",
+					tt.outcome, tt.accounting, txn.OutcomeAmount, txn.AccountingAmount,
+				)
+			}
+
+			inverse := 0
+
+			for _, snapshot := range txn.CourseSnapshots {
+				if snapshot.Inverse {
+					inverse++
+				}
+			}
+
+			if len(txn.CourseSnapshots) != tt.snapshots || inverse != tt.inverse {
+				t.Errorf(
+					"expected %d snapshots with %d inverse, got %d with %d",
+					tt.snapshots, tt.inverse, len(txn.CourseSnapshots), inverse,
+				)
+			}
+		})
+	}
+}
+
+func TestConverter_Apply_Snapshots(t *testing.T) {
+	courses := &courseRepositoryStub{
+		courses: map[string]pkg.Rate{"RUBUSD": pkg.Rate(10000), "USDKZT": pkg.Rate(5 * pkg.RateMultiplier)},
+	}
+	txn := &repository.Transaction{IncomeAmount: 10000, IncomeCurrency: "RUB", OutcomeCurrency: "KZT"}
+
+	if err := NewConverter(courses, "USD", "USD").Apply(context.Background(), &repository.Client{}, txn); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []struct {
+		conversion string
+		pair       string
+		inverse    bool
+	}{
+		{ConversionIncomeToOutcome, "RUBUSD", false},
+		{ConversionIncomeToOutcome, "USDKZT", false},
+		{ConversionIncomeToAccounting, "RUBUSD", false},
+		{ConversionOutcomeToAccounting, "USDKZT", true},
+	}
+
+	if len(txn.CourseSnapshots) != len(expected) {
+		t.Fatalf("expected %d snapshots, got %d", len(expected), len(txn.CourseSnapshots))
+	}
+
+	for i, snapshot := range txn.CourseSnapshots {
+		e := expected[i]
+
+		if snapshot.Conversion != e.conversion || snapshot.From+snapshot.To != e.pair || snapshot.Inverse != e.inverse {
+			t.Errorf(
+				"expected snapshot %d of %s course %s inverse %v, got %s course %s inverse %v",
+				i, e.conversion, e.pair, e.inverse,
+				snapshot.Conversion, snapshot.From+snapshot.To, snapshot.Inverse,
+			)
+		}
+	}
+}
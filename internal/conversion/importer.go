@@ -0,0 +1,230 @@
+package conversion
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FeedFormatCSV = "csv"
+	FeedFormatECB = "ecb"
+
+	// The base currency of ECB reference rates
+	ecbBaseCurrency = "EUR"
+	feedDateLayout  = "2006-01-02"
+)
+
+var (
+	currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+	ErrorFeedFormatUnknown = errors.New("rates feed format is unknown")
+	ErrorFeedEmpty         = errors.New("rates feed doesn't contain rates")
+)
+
+// Importer validates courses from rates feeds and saves them by course repository
+type Importer struct {
+	courses repository.CourseRepositoryInterface
+	// The maximal change percent of course value against previous value of currencies pair, zero disables check
+	maxJump pkg.Percent
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func NewImporter(courses repository.CourseRepositoryInterface, maxJump pkg.Percent) *Importer {
+	importer := &Importer{
+		courses: courses,
+		maxJump: maxJump,
+	}
+	return importer
+}
+
+// ParseFeed reads courses from feed in received format
+func ParseFeed(r io.Reader, format string) ([]*repository.Course, error) {
+	switch format {
+	case FeedFormatCSV:
+		return ParseCSV(r)
+	case FeedFormatECB:
+		return ParseECB(r)
+	}
+
+	return nil, ErrorFeedFormatUnknown
+}
+
+// ParseCSV reads courses from CSV feed with header "from,to,value,date", date is formatted as YYYY-MM-DD or RFC 3339
+func ParseCSV(r io.Reader) ([]*repository.Course, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) < 2 {
+		return nil, ErrorFeedEmpty
+	}
+
+	courses := make([]*repository.Course, 0, len(records)-1)
+
+	for i, record := range records[1:] {
+		course, err := newCourse(record[0], record[1], record[2], record[3])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+// ParseECB reads courses from euro foreign exchange reference rates feed of European Central Bank,
+// all courses of feed are courses of conversion from EUR
+func ParseECB(r io.Reader) ([]*repository.Course, error) {
+	envelope := new(ecbEnvelope)
+
+	if err := xml.NewDecoder(r).Decode(envelope); err != nil {
+		return nil, err
+	}
+
+	courses := make([]*repository.Course, 0)
+
+	for _, day := range envelope.Days {
+		for _, rate := range day.Rates {
+			course, err := newCourse(ecbBaseCurrency, rate.Currency, rate.Rate, day.Time)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", day.Time, rate.Currency, err)
+			}
+
+			courses = append(courses, course)
+		}
+	}
+
+	if len(courses) == 0 {
+		return nil, ErrorFeedEmpty
+	}
+
+	return courses, nil
+}
+
+// Import validates all courses and saves them in one database transaction, nothing is saved if any course
+// is invalid. Currencies of course must be known currencies. Course value is compared with previous value
+// of currencies pair in feed or with value in database which was effective before course date.
+func (m *Importer) Import(ctx context.Context, courses []*repository.Course) error {
+	sort.SliceStable(courses, func(i, j int) bool {
+		return courses[i].Date.Before(courses[j].Date)
+	})
+
+	previous := make(map[string]*repository.Course)
+	errs := make([]string, 0)
+
+	for _, course := range courses {
+		if !pkg.IsKnownCurrency(course.From) || !pkg.IsKnownCurrency(course.To) {
+			errs = append(
+				errs,
+				fmt.Sprintf("%s%s %s: currency is unknown", course.From, course.To, course.Date.Format(feedDateLayout)),
+			)
+			continue
+		}
+
+		key := course.From + course.To
+		prev, ok := previous[key]
+
+		if ok && prev.Date.Equal(course.Date) {
+			errs = append(errs, fmt.Sprintf("%s%s %s: duplicated course", course.From, course.To, course.Date))
+			continue
+		}
+
+		if !ok {
+			var err error
+			prev, err = m.courses.GetCourse(ctx, course.From, course.To, course.Date.Add(-time.Nanosecond))
+
+			if err != nil && err != pkg.ErrorCourseNotFound {
+				return err
+			}
+		}
+
+		if prev != nil && m.isJump(prev.Value, course.Value) {
+			errs = append(
+				errs,
+				fmt.Sprintf("%s%s %s: course %s differs from previous course %s by more than %s%%",
+					course.From, course.To, course.Date.Format(feedDateLayout), course.Value, prev.Value, m.maxJump),
+			)
+		}
+
+		previous[key] = course
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return m.courses.CreateCourses(ctx, courses)
+}
+
+// isJump returns true if course value changed against previous value by more than maximal change percent
+func (m *Importer) isJump(prev, value pkg.Rate) bool {
+	if m.maxJump <= 0 {
+		return false
+	}
+
+	diff := big.NewInt(int64(value - prev))
+	diff.Abs(diff).Mul(diff, big.NewInt(100*pkg.RateMultiplier))
+	limit := new(big.Int).Mul(big.NewInt(int64(prev)), big.NewInt(int64(m.maxJump)))
+
+	return diff.Cmp(limit) > 0
+}
+
+func newCourse(from, to, value, date string) (*repository.Course, error) {
+	from, to = strings.ToUpper(strings.TrimSpace(from)), strings.ToUpper(strings.TrimSpace(to))
+
+	if !currencyRegexp.MatchString(from) || !currencyRegexp.MatchString(to) || from == to {
+		return nil, fmt.Errorf("currencies pair %s%s is invalid", from, to)
+	}
+
+	rate, err := pkg.ParseRate(strings.TrimSpace(value))
+
+	if err != nil {
+		return nil, err
+	}
+
+	effective, err := time.Parse(feedDateLayout, strings.TrimSpace(date))
+
+	if err != nil {
+		effective, err = time.Parse(time.RFC3339, strings.TrimSpace(date))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("date %q is invalid", date)
+	}
+
+	course := &repository.Course{
+		From:  from,
+		To:    to,
+		Value: rate,
+		Date:  effective,
+	}
+	return course, nil
+}
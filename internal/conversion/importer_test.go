@@ -0,0 +1,203 @@
+package conversion
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// courseHistoryStub returns course which was effective at received time from memory and keeps created courses
+type courseHistoryStub struct {
+	repository.CourseRepositoryInterface
+	history []*repository.Course
+	created []*repository.Course
+}
+
+func (m *courseHistoryStub) GetCourse(_ context.Context, from, to string, at time.Time) (*repository.Course, error) {
+	var effective *repository.Course
+
+	for _, course := range m.history {
+		if course.From == from && course.To == to && !course.Date.After(at) &&
+			(effective == nil || course.Date.After(effective.Date)) {
+			effective = course
+		}
+	}
+
+	if effective == nil {
+		return nil, pkg.ErrorCourseNotFound
+	}
+
+	return effective, nil
+}
+
+func (m *courseHistoryStub) CreateCourses(_ context.Context, courses []*repository.Course) error {
+	m.created = courses
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	pkg.SetCurrencyExponents(map[string]int{"USD": 2, "EUR": 2, "RUB": 2, "KZT": 2, "JPY": 0})
+	os.Exit(m.Run())
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		feed  string
+		count int
+		err   bool
+	}{
+		{"courses", "from,to,value,date\nusd, EUR, 0.9, 2020-01-02\nUSD,RUB,75.5,2020-01-02T10:00:00Z\n", 2, false},
+		{"header only", "from,to,value,date\n", 0, true},
+		{"same currencies", "from,to,value,date\nUSD,USD,1,2020-01-02\n", 0, true},
+		{"invalid currency", "from,to,value,date\nUS,EUR,1,2020-01-02\n", 0, true},
+		{"zero rate", "from,to,value,date\nUSD,EUR,0,2020-01-02\n", 0, true},
+		{"invalid date", "from,to,value,date\nUSD,EUR,0.9,02.01.2020\n", 0, true},
+		{"missing field", "from,to,value,date\nUSD,EUR,0.9\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses, err := ParseFeed(strings.NewReader(tt.feed), FeedFormatCSV)
+
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if len(courses) != tt.count {
+				t.Errorf("expected %d courses, got %d", tt.count, len(courses))
+			}
+		})
+	}
+
+	courses, _ := ParseCSV(strings.NewReader("from,to,value,date\nusd, EUR, 0.9, 2020-01-02\n"))
+
+	if c := courses[0]; c.From != "USD" || c.To != "EUR" || c.Value.String() != "0.900000" ||
+		!c.Date.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected course %+v", c)
+	}
+}
+
+func TestParseECB(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2020-01-03">
+			<Cube currency="USD" rate="1.1147"/>
+			<Cube currency="JPY" rate="120.35"/>
+		</Cube>
+		<Cube time="2020-01-02">
+			<Cube currency="USD" rate="1.1193"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	courses, err := ParseFeed(strings.NewReader(feed), FeedFormatECB)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"EURUSD 2020-01-03 1.114700", "EURJPY 2020-01-03 120.350000", "EURUSD 2020-01-02 1.119300"}
+
+	if len(courses) != len(expected) {
+		t.Fatalf("expected %d courses, got %d", len(expected), len(courses))
+	}
+
+	for i, c := range courses {
+		if course := c.From + c.To + " " + c.Date.Format(feedDateLayout) + " " + c.Value.String(); course != expected[i] {
+			t.Errorf("expected course %s, got %s", expected[i], course)
+		}
+	}
+
+	if _, err = ParseFeed(strings.NewReader(`<Envelope><Cube></Cube></Envelope>`), FeedFormatECB); err != ErrorFeedEmpty {
+		t.Errorf("expected error %v, got %v", ErrorFeedEmpty, err)
+	}
+
+	if _, err = ParseFeed(strings.NewReader(feed), "json"); err != ErrorFeedFormatUnknown {
+		t.Errorf("expected error %v, got %v", ErrorFeedFormatUnknown, err)
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	history := []*repository.Course{
+		{From: "USD", To: "RUB", Value: pkg.Rate(70 * pkg.RateMultiplier), Date: date(2020, 1, 1)},
+		{From: "USD", To: "RUB", Value: pkg.Rate(100 * pkg.RateMultiplier), Date: date(2020, 1, 2)},
+	}
+
+	tests := []struct {
+		name string
+		feed string
+		err  string
+	}{
+		{
+			name: "courses within allowed change",
+			feed: "from,to,value,date\nUSD,RUB,75,2020-01-01\nUSD,EUR,0.9,2020-01-01\nUSD,EUR,0.95,2020-01-02\n",
+		},
+		{
+			name: "course compared with course of previous date",
+			feed: "from,to,value,date\nUSD,RUB,110,2020-01-03\n",
+		},
+		{
+			name: "course replacing course of same date compared with course of previous date",
+			feed: "from,to,value,date\nUSD,RUB,90,2020-01-02\n",
+			err:  "USDRUB 2020-01-02: course 90.000000 differs from previous course 70.000000 by more than 10.000000%",
+		},
+		{
+			name: "jump against previous course in feed",
+			feed: "from,to,value,date\nUSD,EUR,0.9,2020-01-01\nUSD,EUR,1.2,2020-01-02\n",
+			err:  "USDEUR 2020-01-02: course 1.200000 differs from previous course 0.900000 by more than 10.000000%",
+		},
+		{
+			name: "duplicated course",
+			feed: "from,to,value,date\nUSD,EUR,0.9,2020-01-01\nUSD,EUR,0.9,2020-01-01\n",
+			err:  "duplicated course",
+		},
+		{
+			name: "unknown currency",
+			feed: "from,to,value,date\nUSD,XYZ,0.9,2020-01-01\n",
+			err:  "USDXYZ 2020-01-01: currency is unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses, err := ParseCSV(strings.NewReader(tt.feed))
+
+			if err != nil {
+				t.Fatalf("feed parsing failed: %v", err)
+			}
+
+			repo := &courseHistoryStub{history: history}
+			err = NewImporter(repo, pkg.Percent(10*pkg.RateMultiplier)).Import(context.Background(), courses)
+
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				if len(repo.created) != len(courses) {
+					t.Errorf("expected %d courses created, got %d", len(courses), len(repo.created))
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+
+			if repo.created != nil {
+				t.Error("courses created despite of invalid course")
+			}
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
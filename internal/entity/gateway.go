@@ -16,14 +16,52 @@ const (
 )
 
 const (
-	GatewaySecurityHashAfterFuncAlgoRsaPkcs1 = "rsa_pkcs1"
-	GatewaySecurityHashAfterFuncAlgoBase64   = "base64"
+	GatewaySecurityHashAlgoNone = "none"
+)
+
+const (
+	GatewaySecurityHashAfterFuncAlgoRsaPkcs1  = "rsa_pkcs1"
+	GatewaySecurityHashAfterFuncAlgoRsaPss    = "rsa_pss"
+	GatewaySecurityHashAfterFuncAlgoHmac      = "hmac"
+	GatewaySecurityHashAfterFuncAlgoBase64    = "base64"
+	GatewaySecurityHashAfterFuncAlgoBase64Url = "base64url"
+	GatewaySecurityHashAfterFuncAlgoHexLower  = "hex"
+	GatewaySecurityHashAfterFuncAlgoHexUpper  = "hex_upper"
 )
 
 type Gateway struct {
-	Name       string
-	HttpClOpts *HttpClientOpts
-	Methods    []*Method
+	// The gateway name, it's equal to handler of providers which payments are processed by gateway
+	Name          string
+	HttpClOpts    *HttpClientOpts
+	Security      *GatewaySecurity
+	StatusPolling *GatewayStatusPolling
+	Methods       []*Method
+	// Rules to receive notifications about payments results from provider, nil if provider doesn't send them
+	Callback *GatewayCallback
+}
+
+type GatewayCallback struct {
+	// Rules to extract values from callback payload, provider signature is extracted to field "signature"
+	// if signature header isn't set
+	Payload *MethodResponse
+	// Template with placeholders of payload fields to calculate callback signature by gateway security hash options
+	SecurityHashTemplate string
+	// Request header with provider signature
+	SignatureHeader string
+	// Flag to accept callbacks without signature verification, i.e. if provider doesn't sign callbacks and they are
+	// received from trusted network only. Gateway building fails if callback signature isn't verified without it.
+	Unsigned bool
+	// Response body to acknowledge callback receiving to provider
+	ResponseBody string
+	// Content type of response to acknowledge callback receiving to provider
+	ResponseContentType string
+}
+
+type GatewayStatusPolling struct {
+	// Interval between requests to gateway status method
+	Interval time.Duration
+	// Maximal count of requests to gateway status method
+	Attempts int
 }
 
 type HttpClientOpts struct {
@@ -39,19 +77,30 @@ type TLS struct {
 
 type GatewaySecurity struct {
 	Type string
+	Hash *GatewaySecurityHashOpts
+	JWT  *GatewaySecurityJWTOpts
 }
 
 type GatewaySecurityJWTOpts struct {
-	Url                    string
-	Headers                []map[string]string
-	RequestMethod          string
-	RequestBody            string
+	Url           string
+	Headers       []map[string]string
+	RequestMethod string
+	RequestBody   string
+	// Dot separated path to token in JSON response of auth endpoint, i.e. "data.access_token"
 	ResponseTokenFieldName string
 	TokenLifetime          time.Duration
+	// Time before token expiration when token must be refreshed
+	RefreshBefore time.Duration
+	// Request header to send token to gateway, Authorization header is used if empty
+	TokenHeaderName string
+	// Prefix of token in request header, "Bearer " is used if token header name is empty
+	TokenHeaderPrefix string
 }
 
 type GatewaySecurityHashOpts struct {
-	Algo      string
+	// Hash algorithm to hash rendered signature template, template is passed to after functions as is if it's none
+	Algo string
+	// Ordered chain of functions which are applied to hash
 	AfterFunc []*GatewaySecurityHashAfterFunc
 }
 
@@ -61,5 +110,8 @@ type GatewaySecurityHashAfterFunc struct {
 }
 
 type GatewaySecurityHashAfterFuncOpts struct {
+	// PEM encoded RSA private key, it may be encoded to base64
 	PrivateKey string
+	// Secret key for HMAC
+	Secret string
 }
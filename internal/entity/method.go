@@ -1,9 +1,62 @@
 package entity
 
+const (
+	MethodResponseFormatJSON = "json"
+	MethodResponseFormatXML  = "xml"
+	MethodResponseFormatText = "text"
+	MethodResponseFormatForm = "form"
+)
+
+const (
+	MethodRoleCheck  = "check"
+	MethodRolePay    = "pay"
+	MethodRoleStatus = "status"
+	MethodRoleCancel = "cancel"
+)
+
+const (
+	MethodResponseFieldProviderTxnId = "provider_txn_id"
+	MethodResponseFieldResultCode    = "result_code"
+	MethodResponseFieldRejectReason  = "reject_reason"
+)
+
 type Method struct {
-	Url                  string
-	RequestMethod        string
-	RequestBody          string
-	RequestHeaders       []map[string]string
+	// The method name which is used to find method in gateway, method role is used if name is empty
+	Name string
+	// The method role in payment flow: check, pay, status or cancel
+	Role string
+	// Gateway API endpoint, may contain placeholders
+	Url string
+	// Http method to request to gateway API endpoint
+	RequestMethod string
+	// Body template with placeholders to request to API endpoint
+	RequestBody string
+	// Headers templates with placeholders to request to API endpoint
+	RequestHeaders []map[string]string
+	// Template with placeholders to create request signature
 	SecurityHashTemplate string
+	// Rules to interpret gateway API response, nil if response must not be interpreted
+	Response *MethodResponse
+}
+
+type MethodResponse struct {
+	// Format of gateway response body: json, xml, form or text
+	Format string
+	// Rules to extract values from gateway response by field name.
+	// Values of fields provider_txn_id, result_code and reject_reason are used to update transaction,
+	// values of other fields are available to caller as is.
+	Fields map[string]*MethodResponseField
+	// Transaction statuses by provider result codes
+	ResultCodes map[string]string
+	// Transaction status if provider result code not found in result codes
+	DefaultStatus string
+}
+
+type MethodResponseField struct {
+	// Path to value in gateway response, for JSON it's dot separated keys and array indexes like "data.items.0.id",
+	// for XML it's slash separated elements names with optional attribute name in end like "response/result/@code".
+	// Whole response body is used if path is empty.
+	Path string
+	// Regexp to extract value from value found by path, first capturing group is used if regexp contains it
+	Regexp string
 }
@@ -0,0 +1,361 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/sidmal/ianua/internal/entity"
+	"github.com/sidmal/ianua/internal/gateway/signature"
+	"github.com/valyala/fasttemplate"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	templateStartTag = "{{"
+	templateEndTag   = "}}"
+
+	// The parameter name under which request signature available in request body and headers templates
+	ParamSignature = "signature"
+)
+
+type Action struct {
+	// The action name
+	Name string
+	// The action role in payment flow
+	Role string
+	// Http method to request to gateway API endpoint
+	Method string
+	// Gateway API endpoint
+	Endpoint string
+	// Body template with placeholders to request to API endpoint
+	Body string
+	// Headers templates with placeholders to request to API endpoint
+	Headers map[string]string
+	// The signing chain to sign request, empty if gateway requests must not be signed
+	Signature signature.Chain
+	// Rules to interpret gateway response, nil if response must not be interpreted
+	Response *ResponseMapping
+
+	// escape escapes parameters values substituted into body template according to body content type
+	escape func(value string) string
+	// endpoint and query are templates of endpoint path and query, query is nil if endpoint hasn't query
+	endpoint  *fasttemplate.Template
+	query     *fasttemplate.Template
+	body      *fasttemplate.Template
+	headers   map[string]*fasttemplate.Template
+	signature *fasttemplate.Template
+}
+
+type Result struct {
+	// Http status code of gateway response
+	StatusCode int
+	// Http headers of gateway response
+	Header http.Header
+	// Raw body of gateway response
+	Body []byte
+	// Gateway response body decoded as JSON object, nil if gateway response isn't JSON object
+	Data map[string]interface{}
+	// Values extracted from gateway response by response mapping rules
+	Fields map[string]string
+	// The transaction unique identifier in provider billing system
+	ProviderTxnId string
+	// The provider result code
+	ResultCode string
+	// The transaction status resolved by provider result code
+	Status string
+	// The transaction reject reason received from provider
+	RejectReason string
+}
+
+func newAction(method *entity.Method, security *entity.GatewaySecurity) (*Action, error) {
+	action := &Action{
+		Name:     method.Name,
+		Role:     method.Role,
+		Method:   strings.ToUpper(method.RequestMethod),
+		Endpoint: method.Url,
+		Body:     method.RequestBody,
+		Headers:  make(map[string]string),
+		headers:  make(map[string]*fasttemplate.Template),
+	}
+
+	if action.Name == "" {
+		action.Name = action.Role
+	}
+
+	if action.Method == "" {
+		action.Method = http.MethodGet
+
+		if action.Body != "" {
+			action.Method = http.MethodPost
+		}
+	}
+
+	var err error
+
+	path, query := action.Endpoint, ""
+
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, query = path[:i], path[i+1:]
+
+		if action.query, err = newTemplate(query); err != nil {
+			return nil, err
+		}
+	}
+
+	if action.endpoint, err = newTemplate(path); err != nil {
+		return nil, err
+	}
+
+	if action.body, err = newTemplate(action.Body); err != nil {
+		return nil, err
+	}
+
+	for _, headers := range method.RequestHeaders {
+		for key, val := range headers {
+			action.Headers[key] = val
+
+			if action.headers[key], err = newTemplate(val); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	action.escape = getEscaper(action.Headers, action.Body)
+
+	if method.Response != nil {
+		if action.Response, err = newResponseMapping(method.Response); err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasSecurityType(security, entity.GatewaySecurityTypeHash) || method.SecurityHashTemplate == "" {
+		return action, nil
+	}
+
+	if security.Hash == nil {
+		return nil, fmt.Errorf("hash security options for gateway method %q not set", method.Name)
+	}
+
+	if action.Signature, err = newSignature(security.Hash); err != nil {
+		return nil, err
+	}
+
+	if action.signature, err = newTemplate(method.SecurityHashTemplate); err != nil {
+		return nil, err
+	}
+
+	return action, nil
+}
+
+// NewRequest renders action templates with received parameters and creates request to gateway API
+func (m *Action) NewRequest(ctx context.Context, params map[string]interface{}) (*http.Request, error) {
+	if len(m.Signature) > 0 {
+		sign, err := m.Signature.GetSignature(render(m.signature, params))
+
+		if err != nil {
+			return nil, err
+		}
+
+		signed := copyParams(params)
+		signed[ParamSignature] = sign
+		params = signed
+	}
+
+	var body io.Reader
+
+	if m.Body != "" {
+		body = strings.NewReader(renderEscaped(m.body, params, m.escape))
+	}
+
+	endpoint := renderEscaped(m.endpoint, params, url.PathEscape)
+
+	if m.query != nil {
+		endpoint += "?" + renderEscaped(m.query, params, url.QueryEscape)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, m.Method, endpoint, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for key, tmpl := range m.headers {
+		req.Header.Set(key, render(tmpl, params))
+	}
+
+	return req, nil
+}
+
+// Execute sends action request to gateway API with received http client and reads gateway response. Response with
+// not successful http status code is an error if action hasn't response mapping to interpret it.
+func (m *Action) Execute(ctx context.Context, cl *http.Client, params map[string]interface{}) (*Result, error) {
+	req, err := m.NewRequest(ctx, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := cl.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	result := &Result{
+		StatusCode: rsp.StatusCode,
+		Header:     rsp.Header,
+	}
+
+	if result.Body, err = ioutil.ReadAll(rsp.Body); err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(rsp.Header.Get("Content-Type"), "json") {
+		_ = json.Unmarshal(result.Body, &result.Data)
+	}
+
+	if m.Response == nil {
+		if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+			return result, fmt.Errorf("gateway action %q returned unexpected status code %d", m.Name, rsp.StatusCode)
+		}
+
+		return result, nil
+	}
+
+	if err = m.Response.Apply(result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// newSignature creates signing chain which hashes data by hash algorithm and applies after functions to hash
+func newSignature(opts *entity.GatewaySecurityHashOpts) (signature.Chain, error) {
+	chain := make(signature.Chain, 0, len(opts.AfterFunc)+1)
+	hash := ""
+
+	if opts.Algo != "" {
+		signer, err := signature.New(opts.Algo, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if h, ok := signer.(*signature.Hash); ok {
+			hash = h.Method
+		}
+
+		chain = append(chain, signer)
+	}
+
+	for _, fn := range opts.AfterFunc {
+		signOpts := &signature.Options{Hash: hash}
+
+		if fn.Opts != nil {
+			signOpts.PrivateKey = fn.Opts.PrivateKey
+			signOpts.Secret = fn.Opts.Secret
+		}
+
+		signer, err := signature.New(fn.Algo, signOpts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, signer)
+	}
+
+	return chain, nil
+}
+
+func newTemplate(tmpl string) (*fasttemplate.Template, error) {
+	return fasttemplate.NewTemplate(tmpl, templateStartTag, templateEndTag)
+}
+
+func render(tmpl *fasttemplate.Template, params map[string]interface{}) string {
+	return renderEscaped(tmpl, params, nil)
+}
+
+// renderEscaped renders template with parameters values escaped by escape function, values are substituted as is
+// if escape function is nil
+func renderEscaped(
+	tmpl *fasttemplate.Template,
+	params map[string]interface{},
+	escape func(value string) string,
+) string {
+	return tmpl.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		val, ok := params[strings.TrimSpace(tag)]
+
+		if !ok || val == nil {
+			return 0, nil
+		}
+
+		var value string
+
+		switch v := val.(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		default:
+			value = fmt.Sprint(val)
+		}
+
+		if escape != nil {
+			value = escape(value)
+		}
+
+		return io.WriteString(w, value)
+	})
+}
+
+// getEscaper returns function to escape parameters values in request body by body content type, content type is
+// detected by body template if it isn't set in request headers. Nil is returned for plain text body.
+func getEscaper(headers map[string]string, body string) func(value string) string {
+	contentType := ""
+
+	for key, val := range headers {
+		if strings.EqualFold(key, "Content-Type") {
+			contentType = strings.ToLower(val)
+		}
+	}
+
+	body = strings.TrimSpace(body)
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		return escapeJSON
+	case strings.Contains(contentType, "xml"):
+		return escapeXML
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		return url.QueryEscape
+	case contentType != "":
+		return nil
+	case strings.HasPrefix(body, "{") || strings.HasPrefix(body, "["):
+		return escapeJSON
+	case strings.HasPrefix(body, "<"):
+		return escapeXML
+	}
+
+	return nil
+}
+
+// escapeJSON escapes value to be placed into JSON string literal
+func escapeJSON(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data[1 : len(data)-1])
+}
+
+func escapeXML(value string) string {
+	buf := new(bytes.Buffer)
+	_ = xml.EscapeText(buf, []byte(value))
+	return buf.String()
+}
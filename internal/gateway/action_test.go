@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/entity"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAction_NewRequest(t *testing.T) {
+	params := map[string]interface{}{
+		"account": `a/b?c&d="e" <f>`,
+		"amount":  100,
+	}
+
+	tests := []struct {
+		name     string
+		method   *entity.Method
+		endpoint string
+		body     string
+	}{
+		{
+			name:     "path value escaped",
+			method:   &entity.Method{Url: "https://gw.test/accounts/{{account}}"},
+			endpoint: "https://gw.test/accounts/a%2Fb%3Fc&d=%22e%22%20%3Cf%3E",
+		},
+		{
+			name:     "query value escaped",
+			method:   &entity.Method{Url: "https://gw.test/pay?account={{ account }}&amount={{amount}}"},
+			endpoint: "https://gw.test/pay?account=a%2Fb%3Fc%26d%3D%22e%22+%3Cf%3E&amount=100",
+		},
+		{
+			name: "json body by content type",
+			method: &entity.Method{
+				Url:            "https://gw.test/pay",
+				RequestBody:    `{"account":"{{account}}","amount":{{amount}}}`,
+				RequestHeaders: []map[string]string{{"content-type": "application/json"}},
+			},
+			endpoint: "https://gw.test/pay",
+			body:     `{"account":"a/b?c\u0026d=\"e\" \u003cf\u003e","amount":100}`,
+		},
+		{
+			name:     "json body by template",
+			method:   &entity.Method{Url: "https://gw.test/pay", RequestBody: `["{{account}}"]`},
+			endpoint: "https://gw.test/pay",
+			body:     `["a/b?c\u0026d=\"e\" \u003cf\u003e"]`,
+		},
+		{
+			name:     "xml body by template",
+			method:   &entity.Method{Url: "https://gw.test/pay", RequestBody: `<account>{{account}}</account>`},
+			endpoint: "https://gw.test/pay",
+			body:     `<account>a/b?c&amp;d=&#34;e&#34; &lt;f&gt;</account>`,
+		},
+		{
+			name: "form body",
+			method: &entity.Method{
+				Url:            "https://gw.test/pay",
+				RequestBody:    `account={{account}}`,
+				RequestHeaders: []map[string]string{{"Content-Type": "application/x-www-form-urlencoded"}},
+			},
+			endpoint: "https://gw.test/pay",
+			body:     `account=a%2Fb%3Fc%26d%3D%22e%22+%3Cf%3E`,
+		},
+		{
+			name: "plain text body as is",
+			method: &entity.Method{
+				Url:            "https://gw.test/pay",
+				RequestBody:    `{{account}}`,
+				RequestHeaders: []map[string]string{{"Content-Type": "text/plain"}},
+			},
+			endpoint: "https://gw.test/pay",
+			body:     `a/b?c&d="e" <f>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := newAction(tt.method, nil)
+
+			if err != nil {
+				t.Fatalf("action building failed: %v", err)
+			}
+
+			req, err := action.NewRequest(context.Background(), params)
+
+			if err != nil {
+				t.Fatalf("request creating failed: %v", err)
+			}
+
+			if endpoint := req.URL.String(); endpoint != tt.endpoint {
+				t.Errorf("expected endpoint %q, got %q", tt.endpoint, endpoint)
+			}
+
+			body := ""
+
+			if req.Body != nil {
+				data, _ := ioutil.ReadAll(req.Body)
+				body = string(data)
+			}
+
+			if body != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, body)
+			}
+		})
+	}
+}
+
+func TestAction_Execute(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response *entity.MethodResponse
+		err      bool
+	}{
+		{"successful status", http.StatusOK, nil, false},
+		{"not successful status without response mapping", http.StatusInternalServerError, nil, true},
+		{"redirect status without response mapping", http.StatusFound, nil, true},
+		{
+			"not successful status interpreted by response mapping",
+			http.StatusBadRequest,
+			&entity.MethodResponse{Format: entity.MethodResponseFormatText, DefaultStatus: StatusRejected},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Location", "/")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			action, err := newAction(&entity.Method{Url: srv.URL, Response: tt.response}, nil)
+
+			if err != nil {
+				t.Fatalf("action building failed: %v", err)
+			}
+
+			cl := &http.Client{
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+			result, err := action.Execute(context.Background(), cl, nil)
+
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if result == nil || result.StatusCode != tt.status {
+				t.Errorf("expected result with status code %d, got %+v", tt.status, result)
+			}
+		})
+	}
+}
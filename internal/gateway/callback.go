@@ -0,0 +1,150 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/sidmal/ianua/internal/entity"
+	"github.com/sidmal/ianua/internal/gateway/signature"
+	"github.com/valyala/fasttemplate"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	ErrorCallbackNotSupported     = errors.New("gateway doesn't receive callbacks")
+	ErrorCallbackSignatureInvalid = errors.New("callback signature is invalid")
+)
+
+type Callback struct {
+	// Rules to extract values from callback payload
+	Payload *ResponseMapping
+	// The signing chain to calculate callback signature, empty if gateway callback is marked as unsigned
+	Signature signature.Chain
+	// Request header with provider signature, signature is taken from payload field "signature" if it's empty
+	SignatureHeader string
+	// Response body to acknowledge callback receiving to provider
+	ResponseBody string
+	// Content type of response to acknowledge callback receiving to provider
+	ResponseContentType string
+
+	signature *fasttemplate.Template
+}
+
+func newCallback(opts *entity.GatewayCallback, security *entity.GatewaySecurity) (*Callback, error) {
+	if opts.Payload == nil {
+		return nil, errors.New("payload rules for gateway callback not set")
+	}
+
+	payload, err := newResponseMapping(opts.Payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	callback := &Callback{
+		Payload:             payload,
+		SignatureHeader:     opts.SignatureHeader,
+		ResponseBody:        opts.ResponseBody,
+		ResponseContentType: opts.ResponseContentType,
+	}
+
+	if !hasSecurityType(security, entity.GatewaySecurityTypeHash) || opts.SecurityHashTemplate == "" {
+		if !opts.Unsigned {
+			return nil, errors.New("gateway callback signature isn't verified, hash security and signature " +
+				"template must be set or callback must be explicitly marked as unsigned")
+		}
+
+		return callback, nil
+	}
+
+	if security.Hash == nil {
+		return nil, errors.New("hash security options for gateway callback not set")
+	}
+
+	if callback.Signature, err = newSignature(security.Hash); err != nil {
+		return nil, err
+	}
+
+	for _, signer := range callback.Signature {
+		if !isVerifiable(signer) {
+			return nil, fmt.Errorf("gateway callback signature can't be verified by signature method %q, "+
+				"only hash, hmac and encoding methods are supported", signer.GetMethodName())
+		}
+	}
+
+	if callback.signature, err = newTemplate(opts.SecurityHashTemplate); err != nil {
+		return nil, err
+	}
+
+	return callback, nil
+}
+
+// isVerifiable reports whether provider signature can be verified by recalculating it with signer. Signers with
+// private key sign by our key, so they can't recalculate provider signature, and some of them aren't deterministic.
+func isVerifiable(signer signature.Signer) bool {
+	switch s := signer.(type) {
+	case *signature.RSA, *signature.ECDSA, *signature.Ed25519:
+		return false
+	case *signature.JWT:
+		return s.Secret != nil
+	}
+
+	return true
+}
+
+// ParseCallback reads callback request from provider, extracts payment result from it and verifies callback
+// signature by recalculating it with gateway security options, so only deterministic signing chains are supported
+func (m *Gateway) ParseCallback(req *http.Request) (*Result, error) {
+	if m.Callback == nil {
+		return nil, ErrorCallbackNotSupported
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) == 0 && req.Method == http.MethodGet {
+		body = []byte(req.URL.RawQuery)
+	}
+
+	result := &Result{
+		StatusCode: http.StatusOK,
+		Header:     req.Header,
+		Body:       body,
+	}
+
+	if err = m.Callback.Payload.Apply(result); err != nil {
+		return nil, err
+	}
+
+	if len(m.Callback.Signature) == 0 {
+		return result, nil
+	}
+
+	received := result.Fields[ParamSignature]
+
+	if m.Callback.SignatureHeader != "" {
+		received = req.Header.Get(m.Callback.SignatureHeader)
+	}
+
+	params := make(map[string]interface{}, len(result.Fields))
+
+	for key, val := range result.Fields {
+		params[key] = val
+	}
+
+	expected, err := m.Callback.Signature.GetSignature(render(m.Callback.signature, params))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if received == "" || subtle.ConstantTimeCompare([]byte(received), []byte(expected)) != 1 {
+		return nil, ErrorCallbackSignatureInvalid
+	}
+
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/sidmal/ianua/internal/entity"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewCallback(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+
+	if err != nil {
+		t.Fatalf("rsa key generating failed: %v", err)
+	}
+
+	privateKey := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+
+	tests := []struct {
+		name     string
+		callback *entity.GatewayCallback
+		security *entity.GatewaySecurity
+		invalid  bool
+	}{
+		{
+			name:     "unsigned",
+			callback: &entity.GatewayCallback{Unsigned: true},
+		},
+		{
+			name:     "signature not verified",
+			callback: &entity.GatewayCallback{},
+			invalid:  true,
+		},
+		{
+			name:     "hmac signature",
+			callback: &entity.GatewayCallback{SecurityHashTemplate: "{{provider_txn_id}}"},
+			security: hashSecurity("none", "hmac", &entity.GatewaySecurityHashAfterFuncOpts{Secret: "s"}),
+		},
+		{
+			name:     "jwt signature by secret",
+			callback: &entity.GatewayCallback{SecurityHashTemplate: "{}"},
+			security: hashSecurity("none", "jwt", &entity.GatewaySecurityHashAfterFuncOpts{Secret: "s"}),
+		},
+		{
+			name:     "rsa signature",
+			callback: &entity.GatewayCallback{SecurityHashTemplate: "{{provider_txn_id}}"},
+			security: hashSecurity("sha256", "rsa_pkcs1", &entity.GatewaySecurityHashAfterFuncOpts{PrivateKey: privateKey}),
+			invalid:  true,
+		},
+		{
+			name:     "jwt signature by private key",
+			callback: &entity.GatewayCallback{SecurityHashTemplate: "{}"},
+			security: hashSecurity("none", "jwt", &entity.GatewaySecurityHashAfterFuncOpts{PrivateKey: privateKey}),
+			invalid:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.callback.Payload = &entity.MethodResponse{Format: entity.MethodResponseFormatForm}
+
+			if _, err := newCallback(tt.callback, tt.security); (err != nil) != tt.invalid {
+				t.Errorf("expected invalid %v, got error %v", tt.invalid, err)
+			}
+		})
+	}
+}
+
+func TestGateway_ParseCallback(t *testing.T) {
+	sign := func(data string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(data))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		sign   string
+		err    error
+	}{
+		{
+			name: "signature in payload",
+			body: "id=p-1&code=0&signature=" + sign("p-1:0"),
+		},
+		{
+			name:   "signature in header",
+			header: "X-Signature",
+			body:   "id=p-1&code=0",
+			sign:   sign("p-1:0"),
+		},
+		{
+			name: "signature of other payload",
+			body: "id=p-1&code=5&signature=" + sign("p-1:0"),
+			err:  ErrorCallbackSignatureInvalid,
+		},
+		{
+			name: "signature not received",
+			body: "id=p-1&code=0",
+			err:  ErrorCallbackSignatureInvalid,
+		},
+		{
+			name:   "signature in payload instead of header",
+			header: "X-Signature",
+			body:   "id=p-1&code=0&signature=" + sign("p-1:0"),
+			err:    ErrorCallbackSignatureInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := newCallback(
+				&entity.GatewayCallback{
+					Payload: &entity.MethodResponse{
+						Format: entity.MethodResponseFormatForm,
+						Fields: map[string]*entity.MethodResponseField{
+							entity.MethodResponseFieldProviderTxnId: {Path: "id"},
+							entity.MethodResponseFieldResultCode:    {Path: "code"},
+							ParamSignature:                          {Path: "signature"},
+						},
+						ResultCodes: map[string]string{"0": StatusCompleted, "5": StatusRejected},
+					},
+					SecurityHashTemplate: "{{provider_txn_id}}:{{result_code}}",
+					SignatureHeader:      tt.header,
+				},
+				hashSecurity("none", "hmac", &entity.GatewaySecurityHashAfterFuncOpts{Secret: "secret"}),
+			)
+
+			if err != nil {
+				t.Fatalf("callback building failed: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/callback/test", strings.NewReader(tt.body))
+
+			if tt.sign != "" {
+				req.Header.Set(tt.header, tt.sign)
+			}
+
+			gw := &Gateway{Name: "test", Callback: callback}
+			result, err := gw.ParseCallback(req)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if err == nil && (result.ProviderTxnId != "p-1" || result.Status != StatusCompleted) {
+				t.Errorf("unexpected callback result %q, %q", result.ProviderTxnId, result.Status)
+			}
+		})
+	}
+}
+
+func hashSecurity(
+	algo, afterFunc string,
+	opts *entity.GatewaySecurityHashAfterFuncOpts,
+) *entity.GatewaySecurity {
+	return &entity.GatewaySecurity{
+		Type: entity.GatewaySecurityTypeHash,
+		Hash: &entity.GatewaySecurityHashOpts{
+			Algo: algo,
+			AfterFunc: []*entity.GatewaySecurityHashAfterFunc{
+				{Algo: afterFunc, Opts: opts},
+				{Algo: "hex"},
+			},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package gateway
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/entity"
+	"time"
+)
+
+// Transaction statuses which can be resolved from provider result codes. Status pending_check isn't among them,
+// because transaction is in it only before payment is sent to provider.
+const (
+	StatusInProgress   = "in_progress"
+	StatusCompleted    = "completed"
+	StatusRejected     = "rejected"
+	StatusManualReview = "manual_review"
+)
+
+// GetActionByRole returns first gateway action with received role in payment flow
+func (m *Gateway) GetActionByRole(role string) (*Action, bool) {
+	for _, action := range m.Actions {
+		if action.Role == role {
+			return action, true
+		}
+	}
+
+	return nil, false
+}
+
+// Check sends account check request to gateway, it's returns nil result if gateway hasn't check action
+func (m *Gateway) Check(ctx context.Context, params map[string]interface{}) (*Result, error) {
+	return m.executeRole(ctx, entity.MethodRoleCheck, params)
+}
+
+// Status sends payment status request to gateway, it's returns nil result if gateway hasn't status action
+func (m *Gateway) Status(ctx context.Context, params map[string]interface{}) (*Result, error) {
+	return m.executeRole(ctx, entity.MethodRoleStatus, params)
+}
+
+// Cancel sends payment cancel request to gateway, it's returns nil result if gateway hasn't cancel action
+func (m *Gateway) Cancel(ctx context.Context, params map[string]interface{}) (*Result, error) {
+	return m.executeRole(ctx, entity.MethodRoleCancel, params)
+}
+
+// Pay processes payment by gateway payment flow: checks account, sends payment and polls payment status until
+// status became final or polling attempts run out. Failed status requests are counted as polling attempts.
+// Account check is skipped if result of account check passed before payment is received.
+// Values extracted from response of each step are available in templates of next steps by field name
+// and by field name with step role prefix, i.e. "check.provider_txn_id".
+func (m *Gateway) Pay(ctx context.Context, params map[string]interface{}, check *Result) (*Result, error) {
+	params = copyParams(params)
+	result := check
+
+	if result == nil {
+		var err error
+
+		if result, err = m.executeRole(ctx, entity.MethodRoleCheck, params); err != nil {
+			return result, err
+		}
+	}
+
+	if result != nil {
+		if result.Status == StatusRejected {
+			return result, nil
+		}
+
+		mergeParams(params, entity.MethodRoleCheck, result)
+	}
+
+	action, ok := m.GetActionByRole(entity.MethodRolePay)
+
+	if !ok {
+		return nil, ErrorActionNotFound
+	}
+
+	result, err := action.Execute(ctx, m.HttpClient, params)
+
+	if err != nil || isFinalStatus(result.Status) {
+		return result, err
+	}
+
+	mergeParams(params, entity.MethodRolePay, result)
+	status, ok := m.GetActionByRole(entity.MethodRoleStatus)
+
+	if !ok || m.StatusPollingAttempts <= 0 {
+		return result, nil
+	}
+
+	ticker := time.NewTicker(m.StatusPollingInterval)
+	defer ticker.Stop()
+
+	for attempt := 0; attempt < m.StatusPollingAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-ticker.C:
+		}
+
+		polled, err := status.Execute(ctx, m.HttpClient, params)
+
+		// status request failure doesn't change known payment status, so polling is continued by next attempt
+		if err != nil {
+			continue
+		}
+
+		if polled.ProviderTxnId == "" {
+			polled.ProviderTxnId = result.ProviderTxnId
+		}
+
+		result = polled
+
+		if isFinalStatus(result.Status) {
+			break
+		}
+
+		mergeParams(params, entity.MethodRoleStatus, result)
+	}
+
+	return result, nil
+}
+
+func (m *Gateway) executeRole(ctx context.Context, role string, params map[string]interface{}) (*Result, error) {
+	action, ok := m.GetActionByRole(role)
+
+	if !ok {
+		return nil, nil
+	}
+
+	return action.Execute(ctx, m.HttpClient, params)
+}
+
+func isFinalStatus(status string) bool {
+	return status == StatusCompleted || status == StatusRejected
+}
+
+func copyParams(params map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(params))
+
+	for key, val := range params {
+		cp[key] = val
+	}
+
+	return cp
+}
+
+func mergeParams(params map[string]interface{}, role string, result *Result) {
+	for key, val := range result.Fields {
+		params[key] = val
+		params[role+"."+key] = val
+	}
+}
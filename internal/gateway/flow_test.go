@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/entity"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGateway_Pay(t *testing.T) {
+	tests := []struct {
+		name string
+		// responses are result codes of gateway responses by action role, empty code is failed response
+		responses map[string][]string
+		noStatus  bool
+		check     *Result
+		status    string
+		requests  map[string]int
+		paid      string
+	}{
+		{
+			name:      "rejected by check",
+			responses: map[string][]string{entity.MethodRoleCheck: {"5"}},
+			status:    StatusRejected,
+			requests:  map[string]int{entity.MethodRoleCheck: 1},
+		},
+		{
+			name:      "completed by pay",
+			responses: map[string][]string{entity.MethodRoleCheck: {"1"}, entity.MethodRolePay: {"0"}},
+			status:    StatusCompleted,
+			requests:  map[string]int{entity.MethodRoleCheck: 1, entity.MethodRolePay: 1},
+			paid:      "token=1",
+		},
+		{
+			name:      "passed pre-check isn't repeated",
+			responses: map[string][]string{entity.MethodRolePay: {"0"}},
+			check:     &Result{Status: StatusInProgress, Fields: map[string]string{"token": "pre"}},
+			status:    StatusCompleted,
+			requests:  map[string]int{entity.MethodRolePay: 1},
+			paid:      "token=pre",
+		},
+		{
+			name: "completed by status polling",
+			responses: map[string][]string{
+				entity.MethodRoleCheck:  {"1"},
+				entity.MethodRolePay:    {"1"},
+				entity.MethodRoleStatus: {"1", "0"},
+			},
+			status:   StatusCompleted,
+			requests: map[string]int{entity.MethodRoleCheck: 1, entity.MethodRolePay: 1, entity.MethodRoleStatus: 2},
+			paid:     "token=1",
+		},
+		{
+			name: "failed status requests counted as attempts",
+			responses: map[string][]string{
+				entity.MethodRoleCheck:  {"1"},
+				entity.MethodRolePay:    {"1"},
+				entity.MethodRoleStatus: {"", "", ""},
+			},
+			status:   StatusInProgress,
+			requests: map[string]int{entity.MethodRoleCheck: 1, entity.MethodRolePay: 1, entity.MethodRoleStatus: 3},
+			paid:     "token=1",
+		},
+		{
+			name:      "pay result returned without status action",
+			responses: map[string][]string{entity.MethodRoleCheck: {"1"}, entity.MethodRolePay: {"1"}},
+			noStatus:  true,
+			status:    StatusInProgress,
+			requests:  map[string]int{entity.MethodRoleCheck: 1, entity.MethodRolePay: 1},
+			paid:      "token=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mx sync.Mutex
+			requests := make(map[string]int)
+			paid := ""
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				role := strings.Trim(r.URL.Path, "/")
+
+				mx.Lock()
+				attempt := requests[role]
+				requests[role]++
+
+				if role == entity.MethodRolePay {
+					paid = r.URL.RawQuery
+				}
+				mx.Unlock()
+
+				codes := tt.responses[role]
+
+				if attempt >= len(codes) || codes[attempt] == "" {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":"` + codes[attempt] + `","token":"` + codes[attempt] + `"}`))
+			}))
+			defer srv.Close()
+
+			response := &entity.MethodResponse{
+				Format: entity.MethodResponseFormatJSON,
+				Fields: map[string]*entity.MethodResponseField{
+					entity.MethodResponseFieldResultCode: {Path: "code"},
+					"token":                              {Path: "token"},
+				},
+				ResultCodes: map[string]string{"0": StatusCompleted, "1": StatusInProgress, "5": StatusRejected},
+			}
+			description := &entity.Gateway{
+				Name:          "test",
+				StatusPolling: &entity.GatewayStatusPolling{Interval: time.Millisecond, Attempts: 3},
+				Methods: []*entity.Method{
+					{Role: entity.MethodRoleCheck, Url: srv.URL + "/check", Response: response},
+					{Role: entity.MethodRolePay, Url: srv.URL + "/pay?token={{check.token}}", Response: response},
+					{Role: entity.MethodRoleStatus, Url: srv.URL + "/status", Response: response},
+				},
+			}
+
+			if tt.noStatus {
+				// without status action result of pay is returned without waiting of polling interval
+				description.StatusPolling.Interval = time.Hour
+				description.Methods = description.Methods[:2]
+			}
+
+			gw, err := BuildGateway(description, zap.NewNop())
+
+			if err != nil {
+				t.Fatalf("gateway building failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			result, err := gw.Pay(ctx, nil, tt.check)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if result.Status != tt.status {
+				t.Errorf("expected status %q, got %q", tt.status, result.Status)
+			}
+
+			for _, role := range []string{entity.MethodRoleCheck, entity.MethodRolePay, entity.MethodRoleStatus} {
+				if requests[role] != tt.requests[role] {
+					t.Errorf("expected %d %s requests, got %d", tt.requests[role], role, requests[role])
+				}
+			}
+
+			if paid != tt.paid {
+				t.Errorf("expected pay request query %q, got %q", tt.paid, paid)
+			}
+		})
+	}
+}
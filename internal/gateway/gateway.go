@@ -1,44 +1,172 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/sidmal/ianua/internal/entity"
-	"github.com/sidmal/ianua/internal/gateway/signature"
 	"go.uber.org/zap"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultResponseWaitTimeout = 30 * time.Second
+)
+
+var (
+	ErrorActionNotFound  = errors.New("gateway action with specified name not found")
+	ErrorGatewayNotFound = errors.New("gateway for provider handler not found")
 )
 
 type Gateway struct {
+	Name       string
 	HttpClient *http.Client
 	Actions    []*Action
+	// Interval between requests to gateway status action in payment flow
+	StatusPollingInterval time.Duration
+	// Maximal count of requests to gateway status action in payment flow
+	StatusPollingAttempts int
+	// Rules to receive notifications about payments results, nil if provider doesn't send them
+	Callback *Callback
 }
 
-type Action struct {
-	// Http method to request to gateway API endpoint
-	Method string
-	// Gateway API endpoint
-	Endpoint string
-	// Body template with placeholders to request to API endpoint
-	Body      string
-	Signature signature.Signer
+// Gateways contains gateways by providers handlers
+type Gateways map[string]*Gateway
+
+// BuildGateway compiles gateway description to gateway which can execute requests to gateway API
+func BuildGateway(gw *entity.Gateway, logger *zap.Logger) (*Gateway, error) {
+	cl, err := newHttpClient(gw.HttpClOpts, logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hasSecurityType(gw.Security, entity.GatewaySecurityTypeJWT) {
+		if err = withJWTSecurity(cl, gw.Security.JWT); err != nil {
+			return nil, err
+		}
+	}
+
+	gateway := &Gateway{
+		Name:       gw.Name,
+		HttpClient: cl,
+		Actions:    make([]*Action, 0, len(gw.Methods)),
+	}
+
+	if gw.StatusPolling != nil {
+		if gw.StatusPolling.Attempts > 0 && gw.StatusPolling.Interval <= 0 {
+			return nil, errors.New("status polling interval of gateway must be positive")
+		}
+
+		gateway.StatusPollingInterval = gw.StatusPolling.Interval
+		gateway.StatusPollingAttempts = gw.StatusPolling.Attempts
+	}
+
+	if gw.Callback != nil {
+		if gateway.Callback, err = newCallback(gw.Callback, gw.Security); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, method := range gw.Methods {
+		action, err := newAction(method, gw.Security)
+
+		if err != nil {
+			return nil, err
+		}
+
+		gateway.Actions = append(gateway.Actions, action)
+	}
+
+	return gateway, nil
 }
 
-type HttpClientSettings struct {
+// LoadGateways builds gateways by descriptions from JSON file
+func LoadGateways(path string, logger *zap.Logger) (Gateways, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptions []*entity.Gateway
+
+	if err = json.Unmarshal(data, &descriptions); err != nil {
+		return nil, err
+	}
+
+	gateways := make(Gateways, len(descriptions))
+
+	for _, description := range descriptions {
+		gateway, err := BuildGateway(description, logger.With(zap.String("gateway", description.Name)))
+
+		if err != nil {
+			return nil, fmt.Errorf("gateway %q building failed: %w", description.Name, err)
+		}
+
+		gateways[description.Name] = gateway
+	}
+
+	return gateways, nil
 }
 
-type Gateways map[string]*Gateway
+// Get returns gateway to process payments to services of provider with received handler
+func (m Gateways) Get(handler string) (*Gateway, error) {
+	gateway, ok := m[handler]
+
+	if !ok {
+		return nil, ErrorGatewayNotFound
+	}
+
+	return gateway, nil
+}
 
-func BuildGateway(gw *entity.Gateway) error {
+func (m *Gateway) GetAction(name string) (*Action, error) {
+	for _, action := range m.Actions {
+		if action.Name == name {
+			return action, nil
+		}
+	}
+
+	return nil, ErrorActionNotFound
+}
+
+// Execute sends request of action with specified name to gateway API and returns gateway response
+func (m *Gateway) Execute(ctx context.Context, name string, params map[string]interface{}) (*Result, error) {
+	action, err := m.GetAction(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return action.Execute(ctx, m.HttpClient, params)
+}
 
+// hasSecurityType checks gateway security type case-insensitively as signature methods names are checked
+func hasSecurityType(security *entity.GatewaySecurity, securityType string) bool {
+	return security != nil && strings.EqualFold(security.Type, securityType)
 }
 
 func newHttpClient(opts *entity.HttpClientOpts, logger *zap.Logger) (*http.Client, error) {
-	transport, err := m.getHttpTransport()
+	if opts == nil {
+		opts = &entity.HttpClientOpts{}
+	}
+
+	if opts.ResponseWaitTimeout <= 0 {
+		opts.ResponseWaitTimeout = defaultResponseWaitTimeout
+	}
+
+	transport, err := getHttpTransport(opts)
 	if err != nil {
 		return nil, err
 	}
 
 	cl := &http.Client{
-		Timeout: m.ResponseWaitTimeout,
+		Timeout: opts.ResponseWaitTimeout,
 		Transport: &HttpTransport{
 			Transport: transport,
 			logger:    logger,
@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sidmal/ianua/internal/entity"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenHeaderName   = "Authorization"
+	defaultTokenHeaderPrefix = "Bearer "
+)
+
+var (
+	ErrorTokenNotFound = errors.New("token not found in response of gateway auth endpoint")
+)
+
+// TokenSource fetches token from gateway auth endpoint and caches it for token lifetime.
+// Concurrent calls share single in-flight request to auth endpoint.
+type TokenSource struct {
+	HttpClient    *http.Client
+	Action        *Action
+	FieldPath     []string
+	Lifetime      time.Duration
+	RefreshBefore time.Duration
+
+	mx     sync.Mutex
+	token  string
+	expire time.Time
+	call   *tokenCall
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+type jwtTransport struct {
+	Transport    http.RoundTripper
+	Source       *TokenSource
+	HeaderName   string
+	HeaderPrefix string
+}
+
+func newTokenSource(opts *entity.GatewaySecurityJWTOpts, cl *http.Client) (*TokenSource, error) {
+	if opts.ResponseTokenFieldName == "" {
+		return nil, errors.New("token field name for gateway jwt security not set")
+	}
+
+	if opts.TokenLifetime <= 0 {
+		return nil, errors.New("token lifetime for gateway jwt security must be positive")
+	}
+
+	if opts.RefreshBefore < 0 || opts.RefreshBefore >= opts.TokenLifetime {
+		return nil, errors.New("token refresh time for gateway jwt security must be less than token lifetime")
+	}
+
+	method := &entity.Method{
+		Name:           entity.GatewaySecurityTypeJWT,
+		Url:            opts.Url,
+		RequestMethod:  opts.RequestMethod,
+		RequestBody:    opts.RequestBody,
+		RequestHeaders: opts.Headers,
+	}
+	action, err := newAction(method, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	source := &TokenSource{
+		HttpClient:    cl,
+		Action:        action,
+		FieldPath:     strings.Split(opts.ResponseTokenFieldName, "."),
+		Lifetime:      opts.TokenLifetime,
+		RefreshBefore: opts.RefreshBefore,
+	}
+	return source, nil
+}
+
+// Token returns cached token or fetches new token if cached token expired or will expire soon
+func (m *TokenSource) Token(ctx context.Context) (string, error) {
+	m.mx.Lock()
+
+	if m.token != "" && time.Now().Before(m.expire.Add(-m.RefreshBefore)) {
+		token := m.token
+		m.mx.Unlock()
+		return token, nil
+	}
+
+	call := m.call
+
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		m.call = call
+		go m.refresh(call)
+	}
+
+	m.mx.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// refresh fetches new token with context which isn't bound to any caller, so cancellation of caller which started
+// fetching doesn't fail other callers waiting for the same token
+func (m *TokenSource) refresh(call *tokenCall) {
+	timeout := m.HttpClient.Timeout
+
+	if timeout <= 0 {
+		timeout = defaultResponseWaitTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	call.token, call.err = m.fetch(ctx)
+
+	m.mx.Lock()
+	if call.err == nil {
+		m.token = call.token
+		m.expire = time.Now().Add(m.Lifetime)
+	}
+	m.call = nil
+	m.mx.Unlock()
+
+	close(call.done)
+}
+
+// Invalidate removes token from cache if it's still cached, i.e. after gateway rejected token
+func (m *TokenSource) Invalidate(token string) {
+	m.mx.Lock()
+	if m.token == token {
+		m.token = ""
+	}
+	m.mx.Unlock()
+}
+
+func (m *TokenSource) fetch(ctx context.Context) (string, error) {
+	result, err := m.Action.Execute(ctx, m.HttpClient, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	if result.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gateway auth endpoint returned unexpected status code %d", result.StatusCode)
+	}
+
+	find, err := jsonFinder(result.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	token, ok := find(m.FieldPath)
+
+	if !ok || token == "" {
+		return "", ErrorTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (m *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := m.Source.Token(req.Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := m.Transport.RoundTrip(m.withToken(req, token))
+
+	if err != nil || rsp.StatusCode != http.StatusUnauthorized || req.GetBody == nil && req.Body != nil {
+		return rsp, err
+	}
+
+	rsp.Body.Close()
+	m.Source.Invalidate(token)
+
+	if token, err = m.Source.Token(req.Context()); err != nil {
+		return nil, err
+	}
+
+	retry := m.withToken(req, token)
+
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.Transport.RoundTrip(retry)
+}
+
+func (m *jwtTransport) withToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set(m.HeaderName, m.HeaderPrefix+token)
+	return req
+}
+
+func withJWTSecurity(cl *http.Client, opts *entity.GatewaySecurityJWTOpts) error {
+	if opts == nil {
+		return errors.New("jwt security options for gateway not set")
+	}
+
+	source, err := newTokenSource(opts, &http.Client{Timeout: cl.Timeout, Transport: cl.Transport})
+
+	if err != nil {
+		return err
+	}
+
+	transport := &jwtTransport{
+		Transport:    cl.Transport,
+		Source:       source,
+		HeaderName:   opts.TokenHeaderName,
+		HeaderPrefix: opts.TokenHeaderPrefix,
+	}
+
+	if transport.HeaderName == "" {
+		transport.HeaderName = defaultTokenHeaderName
+		transport.HeaderPrefix = defaultTokenHeaderPrefix
+	}
+
+	cl.Transport = transport
+	return nil
+}
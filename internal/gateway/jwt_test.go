@@ -0,0 +1,149 @@
+package gateway
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/entity"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewTokenSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *entity.GatewaySecurityJWTOpts
+		invalid bool
+	}{
+		{"valid", &entity.GatewaySecurityJWTOpts{ResponseTokenFieldName: "token", TokenLifetime: time.Hour}, false},
+		{"token field not set", &entity.GatewaySecurityJWTOpts{TokenLifetime: time.Hour}, true},
+		{"zero lifetime", &entity.GatewaySecurityJWTOpts{ResponseTokenFieldName: "token"}, true},
+		{
+			"refresh time exceeds lifetime",
+			&entity.GatewaySecurityJWTOpts{
+				ResponseTokenFieldName: "token",
+				TokenLifetime:          time.Minute,
+				RefreshBefore:          time.Minute,
+			},
+			true,
+		},
+		{
+			"negative refresh time",
+			&entity.GatewaySecurityJWTOpts{
+				ResponseTokenFieldName: "token",
+				TokenLifetime:          time.Minute,
+				RefreshBefore:          -time.Second,
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTokenSource(tt.opts, http.DefaultClient); (err != nil) != tt.invalid {
+				t.Errorf("expected invalid %v, got error %v", tt.invalid, err)
+			}
+		})
+	}
+}
+
+func TestJWTTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		// auth returns status code and body of auth endpoint response by request number started from 1
+		auth     func(n int64) (int, string)
+		valid    string
+		requests int
+		fetches  int64
+		err      bool
+	}{
+		{
+			name:     "token cached",
+			auth:     issueToken,
+			valid:    "t1",
+			requests: 2,
+			fetches:  1,
+		},
+		{
+			name:     "rejected token refreshed and request retried",
+			auth:     issueToken,
+			valid:    "t2",
+			requests: 1,
+			fetches:  2,
+		},
+		{
+			name:     "token not found in auth response",
+			auth:     func(int64) (int, string) { return http.StatusOK, `{"data":{}}` },
+			requests: 1,
+			fetches:  1,
+			err:      true,
+		},
+		{
+			name:     "auth endpoint failed",
+			auth:     func(int64) (int, string) { return http.StatusInternalServerError, `` },
+			requests: 1,
+			fetches:  1,
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := int64(0)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/auth" {
+					status, body := tt.auth(atomic.AddInt64(&fetches, 1))
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(status)
+					_, _ = w.Write([]byte(body))
+					return
+				}
+
+				if r.Header.Get("X-Token") != "JWT "+tt.valid {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer srv.Close()
+
+			gw, err := BuildGateway(&entity.Gateway{
+				Name: "test",
+				Security: &entity.GatewaySecurity{
+					Type: entity.GatewaySecurityTypeJWT,
+					JWT: &entity.GatewaySecurityJWTOpts{
+						Url:                    srv.URL + "/auth",
+						RequestMethod:          http.MethodPost,
+						ResponseTokenFieldName: "data.token",
+						TokenLifetime:          time.Hour,
+						TokenHeaderName:        "X-Token",
+						TokenHeaderPrefix:      "JWT ",
+					},
+				},
+				Methods: []*entity.Method{{Name: "api", Url: srv.URL + "/api"}},
+			}, zap.NewNop())
+
+			if err != nil {
+				t.Fatalf("gateway building failed: %v", err)
+			}
+
+			for i := 0; i < tt.requests; i++ {
+				_, err = gw.Execute(context.Background(), "api", nil)
+
+				if (err != nil) != tt.err {
+					t.Fatalf("expected error %v, got %v", tt.err, err)
+				}
+			}
+
+			if fetches != tt.fetches {
+				t.Errorf("expected %d token fetches, got %d", tt.fetches, fetches)
+			}
+		})
+	}
+}
+
+func issueToken(n int64) (int, string) {
+	return http.StatusOK, `{"data":{"token":"t` + strconv.FormatInt(n, 10) + `"}}`
+}
@@ -0,0 +1,284 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/sidmal/ianua/internal/entity"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ResponseMapping struct {
+	// Format of gateway response body
+	Format string
+	// Transaction statuses by provider result codes
+	ResultCodes map[string]string
+	// Transaction status if provider result code not found in result codes
+	DefaultStatus string
+
+	fields map[string]*fieldExtractor
+}
+
+type fieldExtractor struct {
+	path   []string
+	regexp *regexp.Regexp
+}
+
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []*xmlNode `xml:",any"`
+}
+
+func newResponseMapping(opts *entity.MethodResponse) (*ResponseMapping, error) {
+	mapping := &ResponseMapping{
+		Format:        strings.ToLower(opts.Format),
+		ResultCodes:   opts.ResultCodes,
+		DefaultStatus: opts.DefaultStatus,
+		fields:        make(map[string]*fieldExtractor, len(opts.Fields)),
+	}
+
+	for code, status := range mapping.ResultCodes {
+		if !isKnownStatus(status) {
+			return nil, fmt.Errorf("unknown transaction status %q for gateway result code %q", status, code)
+		}
+	}
+
+	if mapping.DefaultStatus != "" && !isKnownStatus(mapping.DefaultStatus) {
+		return nil, fmt.Errorf("unknown gateway default transaction status %q", mapping.DefaultStatus)
+	}
+
+	separator := ""
+
+	switch mapping.Format {
+	case entity.MethodResponseFormatJSON:
+		separator = "."
+	case entity.MethodResponseFormatXML:
+		separator = "/"
+	case entity.MethodResponseFormatForm:
+		separator = "."
+	case entity.MethodResponseFormatText:
+	default:
+		return nil, fmt.Errorf("unsupported gateway response format %q", opts.Format)
+	}
+
+	for name, field := range opts.Fields {
+		extractor := &fieldExtractor{}
+
+		if separator != "" && field.Path != "" {
+			extractor.path = strings.Split(strings.Trim(field.Path, separator), separator)
+		}
+
+		if field.Regexp != "" {
+			re, err := regexp.Compile(field.Regexp)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp for gateway response field %q: %w", name, err)
+			}
+
+			extractor.regexp = re
+		}
+
+		mapping.fields[name] = extractor
+	}
+
+	return mapping, nil
+}
+
+// Apply extracts fields values from gateway response body and fills result by them
+func (m *ResponseMapping) Apply(result *Result) error {
+	var (
+		find func(path []string) (string, bool)
+		err  error
+	)
+
+	switch m.Format {
+	case entity.MethodResponseFormatJSON:
+		find, err = jsonFinder(result.Body)
+	case entity.MethodResponseFormatXML:
+		find, err = xmlFinder(result.Body)
+	case entity.MethodResponseFormatForm:
+		find, err = formFinder(result.Body)
+	default:
+		find = func([]string) (string, bool) { return "", false }
+	}
+
+	if err != nil {
+		return fmt.Errorf("gateway response can't be parsed as %s: %w", m.Format, err)
+	}
+
+	result.Fields = make(map[string]string, len(m.fields))
+
+	for name, field := range m.fields {
+		val := string(result.Body)
+
+		if len(field.path) > 0 {
+			var ok bool
+
+			if val, ok = find(field.path); !ok {
+				continue
+			}
+		}
+
+		if field.regexp != nil {
+			matches := field.regexp.FindStringSubmatch(val)
+
+			if matches == nil {
+				continue
+			}
+
+			val = matches[0]
+
+			if len(matches) > 1 {
+				val = matches[1]
+			}
+		}
+
+		result.Fields[name] = val
+	}
+
+	result.ProviderTxnId = result.Fields[entity.MethodResponseFieldProviderTxnId]
+	result.ResultCode = result.Fields[entity.MethodResponseFieldResultCode]
+	result.RejectReason = result.Fields[entity.MethodResponseFieldRejectReason]
+
+	status, ok := m.ResultCodes[result.ResultCode]
+
+	if !ok {
+		status = m.DefaultStatus
+	}
+
+	result.Status = status
+
+	return nil
+}
+
+func isKnownStatus(status string) bool {
+	return status == StatusInProgress || status == StatusCompleted || status == StatusRejected ||
+		status == StatusManualReview
+}
+
+func jsonFinder(body []byte) (func(path []string) (string, bool), error) {
+	var root interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+
+	find := func(path []string) (string, bool) {
+		node := root
+
+		for _, key := range path {
+			switch v := node.(type) {
+			case map[string]interface{}:
+				val, ok := v[key]
+
+				if !ok {
+					return "", false
+				}
+
+				node = val
+			case []interface{}:
+				idx, err := strconv.Atoi(key)
+
+				if err != nil || idx < 0 || idx >= len(v) {
+					return "", false
+				}
+
+				node = v[idx]
+			default:
+				return "", false
+			}
+		}
+
+		switch v := node.(type) {
+		case nil:
+			return "", false
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+
+		b, err := json.Marshal(node)
+		return string(b), err == nil
+	}
+
+	return find, nil
+}
+
+func xmlFinder(body []byte) (func(path []string) (string, bool), error) {
+	root := new(xmlNode)
+
+	if err := xml.Unmarshal(body, root); err != nil {
+		return nil, err
+	}
+
+	find := func(path []string) (string, bool) {
+		if path[0] != root.XMLName.Local {
+			return "", false
+		}
+
+		node := root
+
+		for _, name := range path[1:] {
+			if strings.HasPrefix(name, "@") {
+				for _, attr := range node.Attrs {
+					if attr.Name.Local == name[1:] {
+						return attr.Value, true
+					}
+				}
+
+				return "", false
+			}
+
+			var next *xmlNode
+
+			for _, child := range node.Nodes {
+				if child.XMLName.Local == name {
+					next = child
+					break
+				}
+			}
+
+			if next == nil {
+				return "", false
+			}
+
+			node = next
+		}
+
+		return strings.TrimSpace(node.Content), true
+	}
+
+	return find, nil
+}
+
+func formFinder(body []byte) (func(path []string) (string, bool), error) {
+	values, err := url.ParseQuery(string(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	find := func(path []string) (string, bool) {
+		val, ok := values[strings.Join(path, ".")]
+
+		if !ok || len(val) == 0 {
+			return "", false
+		}
+
+		return val[0], true
+	}
+
+	return find, nil
+}
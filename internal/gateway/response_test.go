@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"github.com/sidmal/ianua/internal/entity"
+	"testing"
+)
+
+func TestResponseMapping_Apply(t *testing.T) {
+	resultCodes := map[string]string{
+		"0":  StatusCompleted,
+		"1":  StatusInProgress,
+		"5":  StatusRejected,
+		"99": StatusManualReview,
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		fields   map[string]*entity.MethodResponseField
+		body     string
+		expected *Result
+	}{
+		{
+			name:   "json",
+			format: entity.MethodResponseFormatJSON,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldProviderTxnId: {Path: "data.items.0.id"},
+				entity.MethodResponseFieldResultCode:    {Path: "code"},
+			},
+			body:     `{"code":0,"data":{"items":[{"id":"p-1"}]}}`,
+			expected: &Result{ProviderTxnId: "p-1", ResultCode: "0", Status: StatusCompleted},
+		},
+		{
+			name:   "xml with attribute",
+			format: entity.MethodResponseFormatXML,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldResultCode:   {Path: "response/result/@code"},
+				entity.MethodResponseFieldRejectReason: {Path: "response/result"},
+			},
+			body:     `<response><result code="5"> account blocked </result></response>`,
+			expected: &Result{ResultCode: "5", RejectReason: "account blocked", Status: StatusRejected},
+		},
+		{
+			name:   "form",
+			format: entity.MethodResponseFormatForm,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldResultCode: {Path: "result.code"},
+			},
+			body:     `result.code=99&other=1`,
+			expected: &Result{ResultCode: "99", Status: StatusManualReview},
+		},
+		{
+			name:   "text with regexp capturing group",
+			format: entity.MethodResponseFormatText,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldResultCode: {Regexp: `CODE=(\d+)`},
+			},
+			body:     `STATUS OK CODE=1`,
+			expected: &Result{ResultCode: "1", Status: StatusInProgress},
+		},
+		{
+			name:   "unknown result code mapped to default status",
+			format: entity.MethodResponseFormatJSON,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldResultCode: {Path: "code"},
+			},
+			body:     `{"code":"42"}`,
+			expected: &Result{ResultCode: "42", Status: StatusInProgress},
+		},
+		{
+			name:   "missing field mapped to default status",
+			format: entity.MethodResponseFormatJSON,
+			fields: map[string]*entity.MethodResponseField{
+				entity.MethodResponseFieldResultCode: {Path: "code"},
+			},
+			body:     `{}`,
+			expected: &Result{Status: StatusInProgress},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := newResponseMapping(&entity.MethodResponse{
+				Format:        tt.format,
+				Fields:        tt.fields,
+				ResultCodes:   resultCodes,
+				DefaultStatus: StatusInProgress,
+			})
+
+			if err != nil {
+				t.Fatalf("response mapping building failed: %v", err)
+			}
+
+			result := &Result{Body: []byte(tt.body)}
+
+			if err = mapping.Apply(result); err != nil {
+				t.Fatalf("response mapping applying failed: %v", err)
+			}
+
+			if result.ProviderTxnId != tt.expected.ProviderTxnId || result.ResultCode != tt.expected.ResultCode ||
+				result.RejectReason != tt.expected.RejectReason || result.Status != tt.expected.Status {
+				t.Errorf(
+					"expected %q, %q, %q, %q, got %q, %q, %q, %q",
+					tt.expected.ProviderTxnId, tt.expected.ResultCode, tt.expected.RejectReason, tt.expected.Status,
+					result.ProviderTxnId, result.ResultCode, result.RejectReason, result.Status,
+				)
+			}
+		})
+	}
+}
+
+func TestNewResponseMapping_Statuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *entity.MethodResponse
+		invalid bool
+	}{
+		{"known statuses", &entity.MethodResponse{Format: "json", ResultCodes: resultCodesOf(StatusCompleted)}, false},
+		{"manual review", &entity.MethodResponse{Format: "json", ResultCodes: resultCodesOf(StatusManualReview)}, false},
+		{"pending check", &entity.MethodResponse{Format: "json", ResultCodes: resultCodesOf("pending_check")}, true},
+		{"unknown status", &entity.MethodResponse{Format: "json", ResultCodes: resultCodesOf("done")}, true},
+		{"unknown default status", &entity.MethodResponse{Format: "json", DefaultStatus: "done"}, true},
+		{"unsupported format", &entity.MethodResponse{Format: "yaml"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newResponseMapping(tt.opts); (err != nil) != tt.invalid {
+				t.Errorf("expected invalid %v, got error %v", tt.invalid, err)
+			}
+		})
+	}
+}
+
+func resultCodesOf(status string) map[string]string {
+	return map[string]string{"0": status}
+}
@@ -0,0 +1,51 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+)
+
+type ECDSA struct {
+	Hash crypto.Hash
+	Key  *ecdsa.PrivateKey
+}
+
+func init() {
+	Register(MethodECDSA, func(opts *Options) (Signer, error) {
+		h, err := getHash(opts.Hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parsePrivateKey(opts.PrivateKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+
+		if !ok {
+			return nil, errors.New("private key isn't ECDSA key")
+		}
+
+		return &ECDSA{Hash: h, Key: ecKey}, nil
+	})
+}
+
+func (m *ECDSA) GetMethodName() string {
+	return MethodECDSA
+}
+
+func (m *ECDSA) Sign(in *Data) (*Data, error) {
+	sign, err := ecdsa.SignASN1(rand.Reader, m.Key, digest(m.Hash, in))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Data{Bytes: sign}, nil
+}
@@ -0,0 +1,36 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"errors"
+)
+
+type Ed25519 struct {
+	Key ed25519.PrivateKey
+}
+
+func init() {
+	Register(MethodEd25519, func(opts *Options) (Signer, error) {
+		key, err := parsePrivateKey(opts.PrivateKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		edKey, ok := key.(ed25519.PrivateKey)
+
+		if !ok {
+			return nil, errors.New("private key isn't Ed25519 key")
+		}
+
+		return &Ed25519{Key: edKey}, nil
+	})
+}
+
+func (m *Ed25519) GetMethodName() string {
+	return MethodEd25519
+}
+
+func (m *Ed25519) Sign(in *Data) (*Data, error) {
+	return &Data{Bytes: ed25519.Sign(m.Key, in.Bytes)}, nil
+}
@@ -0,0 +1,38 @@
+package signature
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+type Encoder struct {
+	Method string
+	Encode func(data []byte) string
+}
+
+func init() {
+	encoders := map[string]func(data []byte) string{
+		MethodBase64:    base64.StdEncoding.EncodeToString,
+		MethodBase64Url: base64.URLEncoding.EncodeToString,
+		MethodHexLower:  hex.EncodeToString,
+		MethodHexUpper: func(data []byte) string {
+			return strings.ToUpper(hex.EncodeToString(data))
+		},
+	}
+
+	for method, fn := range encoders {
+		method, fn := method, fn
+		Register(method, func(*Options) (Signer, error) {
+			return &Encoder{Method: method, Encode: fn}, nil
+		})
+	}
+}
+
+func (m *Encoder) GetMethodName() string {
+	return m.Method
+}
+
+func (m *Encoder) Sign(in *Data) (*Data, error) {
+	return &Data{Bytes: []byte(m.Encode(in.Bytes)), Encoded: true}, nil
+}
@@ -0,0 +1,71 @@
+package signature
+
+import (
+	"crypto"
+	_ "crypto/md5"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+	"strings"
+)
+
+var (
+	hashes = map[string]crypto.Hash{
+		MethodMD5:    crypto.MD5,
+		MethodSHA1:   crypto.SHA1,
+		MethodSHA256: crypto.SHA256,
+		MethodSHA512: crypto.SHA512,
+	}
+)
+
+type Hash struct {
+	Method string
+	Hash   crypto.Hash
+}
+
+func init() {
+	for method, h := range hashes {
+		method, h := method, h
+		Register(method, func(*Options) (Signer, error) {
+			return &Hash{Method: method, Hash: h}, nil
+		})
+	}
+}
+
+func (m *Hash) GetMethodName() string {
+	return m.Method
+}
+
+func (m *Hash) Sign(in *Data) (*Data, error) {
+	return &Data{Bytes: hashSum(m.Hash, in.Bytes), Hash: m.Hash}, nil
+}
+
+func getHash(name string) (crypto.Hash, error) {
+	if name == "" {
+		return crypto.SHA256, nil
+	}
+
+	h, ok := hashes[strings.ToLower(name)]
+
+	if !ok {
+		return 0, fmt.Errorf("unsupported hash algorithm %q", name)
+	}
+
+	return h, nil
+}
+
+func hashSum(h crypto.Hash, data []byte) []byte {
+	hh := h.New()
+	hh.Write(data)
+	return hh.Sum(nil)
+}
+
+// digest returns digest of data calculated by received hash, data is returned as is if it's already such digest
+func digest(h crypto.Hash, in *Data) []byte {
+	if in.Hash == h {
+		return in.Bytes
+	}
+
+	return hashSum(h, in.Bytes)
+}
@@ -0,0 +1,53 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"errors"
+)
+
+type HMAC struct {
+	Method string
+	Hash   crypto.Hash
+	Secret []byte
+}
+
+func init() {
+	Register(MethodHMAC, newHMAC(MethodHMAC, ""))
+	Register(MethodHMACMD5, newHMAC(MethodHMACMD5, MethodMD5))
+	Register(MethodHMACSHA1, newHMAC(MethodHMACSHA1, MethodSHA1))
+	Register(MethodHMACSHA256, newHMAC(MethodHMACSHA256, MethodSHA256))
+	Register(MethodHMACSHA512, newHMAC(MethodHMACSHA512, MethodSHA512))
+}
+
+func newHMAC(method, hash string) Constructor {
+	return func(opts *Options) (Signer, error) {
+		if opts.Secret == "" {
+			return nil, errors.New("secret not set")
+		}
+
+		name := hash
+
+		if name == "" {
+			name = opts.Hash
+		}
+
+		h, err := getHash(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &HMAC{Method: method, Hash: h, Secret: []byte(opts.Secret)}, nil
+	}
+}
+
+func (m *HMAC) GetMethodName() string {
+	return m.Method
+}
+
+func (m *HMAC) Sign(in *Data) (*Data, error) {
+	mac := hmac.New(m.Hash.New, m.Secret)
+	mac.Write(in.Bytes)
+	return &Data{Bytes: mac.Sum(nil)}, nil
+}
@@ -0,0 +1,116 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JWT signs data as payload of JSON Web Token, algorithm is selected by secret or private key type
+type JWT struct {
+	Alg    string
+	Hash   crypto.Hash
+	Secret []byte
+	Key    crypto.Signer
+}
+
+func init() {
+	Register(MethodJWT, func(opts *Options) (Signer, error) {
+		h, err := getHash(opts.Hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bits := map[crypto.Hash]string{crypto.SHA256: "256", crypto.SHA512: "512"}[h]
+
+		if bits == "" {
+			return nil, fmt.Errorf("hash algorithm %q isn't allowed for jwt", opts.Hash)
+		}
+
+		signer := &JWT{Hash: h}
+
+		if opts.Secret != "" {
+			signer.Alg = "HS" + bits
+			signer.Secret = []byte(opts.Secret)
+			return signer, nil
+		}
+
+		key, err := parsePrivateKey(opts.PrivateKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			signer.Alg = "RS" + bits
+			signer.Key = k
+		case *ecdsa.PrivateKey:
+			signer.Alg = "ES" + bits
+			signer.Key = k
+		case ed25519.PrivateKey:
+			signer.Alg = "EdDSA"
+			signer.Key = k
+		default:
+			return nil, errors.New("unsupported private key type for jwt")
+		}
+
+		return signer, nil
+	})
+}
+
+func (m *JWT) GetMethodName() string {
+	return MethodJWT
+}
+
+func (m *JWT) Sign(in *Data) (*Data, error) {
+	if !json.Valid(in.Bytes) {
+		return nil, errors.New("jwt payload isn't valid JSON")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": m.Alg, "typ": "JWT"})
+	token := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(in.Bytes)
+	sign, err := m.sign([]byte(token))
+
+	if err != nil {
+		return nil, err
+	}
+
+	token += "." + base64.RawURLEncoding.EncodeToString(sign)
+	return &Data{Bytes: []byte(token), Encoded: true}, nil
+}
+
+func (m *JWT) sign(data []byte) ([]byte, error) {
+	if m.Secret != nil {
+		mac := hmac.New(m.Hash.New, m.Secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+
+	switch k := m.Key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(k, data), nil
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, hashSum(m.Hash, data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sign := make([]byte, 2*size)
+		r.FillBytes(sign[:size])
+		s.FillBytes(sign[size:])
+		return sign, nil
+	}
+
+	return m.Key.Sign(rand.Reader, hashSum(m.Hash, data), m.Hash)
+}
@@ -0,0 +1,44 @@
+package signature
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+// parsePrivateKey parses PEM encoded PKCS#1, SEC 1 or PKCS#8 private key, PEM may be encoded to base64
+func parsePrivateKey(key string) (interface{}, error) {
+	if key == "" {
+		return nil, errors.New("private key not set")
+	}
+
+	data := []byte(key)
+
+	if !strings.HasPrefix(strings.TrimSpace(key), "-----") {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = decoded
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("private key isn't PEM encoded")
+	}
+
+	if pk, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return pk, nil
+	}
+
+	if pk, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return pk, nil
+	}
+
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
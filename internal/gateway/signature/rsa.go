@@ -0,0 +1,69 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+)
+
+type RSA struct {
+	Method string
+	Hash   crypto.Hash
+	Key    *rsa.PrivateKey
+	// Flag to sign by RSA-PSS instead of RSA PKCS#1 v1.5
+	PSS bool
+}
+
+func init() {
+	Register(MethodRSA, newRSA(MethodRSA, false))
+	Register(MethodRSAPKCS1, newRSA(MethodRSAPKCS1, false))
+	Register(MethodRSAPSS, newRSA(MethodRSAPSS, true))
+}
+
+func newRSA(method string, pss bool) Constructor {
+	return func(opts *Options) (Signer, error) {
+		h, err := getHash(opts.Hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parsePrivateKey(opts.PrivateKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rsaKey, ok := key.(*rsa.PrivateKey)
+
+		if !ok {
+			return nil, errors.New("private key isn't RSA key")
+		}
+
+		return &RSA{Method: method, Hash: h, Key: rsaKey, PSS: pss}, nil
+	}
+}
+
+func (m *RSA) GetMethodName() string {
+	return m.Method
+}
+
+func (m *RSA) Sign(in *Data) (*Data, error) {
+	var (
+		sign []byte
+		err  error
+	)
+
+	if m.PSS {
+		sign, err = rsa.SignPSS(rand.Reader, m.Key, m.Hash, digest(m.Hash, in), nil)
+	} else {
+		sign, err = rsa.SignPKCS1v15(rand.Reader, m.Key, m.Hash, digest(m.Hash, in))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Data{Bytes: sign}, nil
+}
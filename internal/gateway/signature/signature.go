@@ -1,14 +1,154 @@
 package signature
 
-import "github.com/valyala/fasttemplate"
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
 
 const (
-	MethodNone = "none"
-	MethodMD5  = "md5"
-	MethodJWT  = "jwt"
-	MethodRSA  = "rsa"
+	MethodNone       = "none"
+	MethodMD5        = "md5"
+	MethodSHA1       = "sha1"
+	MethodSHA256     = "sha256"
+	MethodSHA512     = "sha512"
+	MethodHMAC       = "hmac"
+	MethodHMACMD5    = "hmac-md5"
+	MethodHMACSHA1   = "hmac-sha1"
+	MethodHMACSHA256 = "hmac-sha256"
+	MethodHMACSHA512 = "hmac-sha512"
+	MethodRSA        = "rsa"
+	MethodRSAPKCS1   = "rsa_pkcs1"
+	MethodRSAPSS     = "rsa_pss"
+	MethodECDSA      = "ecdsa"
+	MethodEd25519    = "ed25519"
+	MethodJWT        = "jwt"
+	MethodBase64     = "base64"
+	MethodBase64Url  = "base64url"
+	MethodHexLower   = "hex"
+	MethodHexUpper   = "hex_upper"
+)
+
+var (
+	ErrorUnknownMethod = errors.New("unknown signature method")
+
+	registry = make(map[string]Constructor)
+	mx       sync.RWMutex
 )
 
+// Data is data which is passed through signing chain
+type Data struct {
+	Bytes []byte
+	// Hash by which bytes were calculated, zero if bytes aren't digest
+	Hash crypto.Hash
+	// Flag that bytes are text encoding of binary data
+	Encoded bool
+}
+
+type Options struct {
+	// Hash algorithm name for hmac, rsa, ecdsa and jwt methods, sha256 is used if empty
+	Hash string
+	// PEM encoded private key, it may be encoded to base64
+	PrivateKey string
+	// Secret key for hmac and jwt methods
+	Secret string
+}
+
 type Signer interface {
-	GetMethodNameGetSignature(tmpl *fasttemplate.Template, params map[string]interface{}) string
+	GetMethodName() string
+	Sign(in *Data) (*Data, error)
+}
+
+type Constructor func(opts *Options) (Signer, error)
+
+// Chain is ordered chain of signers, output of each signer is input of next signer
+type Chain []Signer
+
+// Register makes signature method available by name, it panics if method with same name already registered
+func Register(method string, fn Constructor) {
+	mx.Lock()
+	defer mx.Unlock()
+
+	if _, ok := registry[method]; ok {
+		panic("signature method " + method + " already registered")
+	}
+
+	registry[method] = fn
+}
+
+// New creates signer of method with received name, options are validated by method constructor
+func New(method string, opts *Options) (Signer, error) {
+	mx.RLock()
+	fn, ok := registry[strings.ToLower(method)]
+	mx.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrorUnknownMethod, method)
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	signer, err := fn(opts)
+
+	if err != nil {
+		return nil, fmt.Errorf("signature method %q: %w", method, err)
+	}
+
+	return signer, nil
+}
+
+func (m Chain) GetMethodName() string {
+	names := make([]string, len(m))
+
+	for i, signer := range m {
+		names[i] = signer.GetMethodName()
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (m Chain) Sign(in *Data) (*Data, error) {
+	var err error
+
+	for _, signer := range m {
+		if in, err = signer.Sign(in); err != nil {
+			return nil, err
+		}
+	}
+
+	return in, nil
+}
+
+// GetSignature signs string by chain, result is encoded to base64 if last signer in chain isn't encoder
+func (m Chain) GetSignature(str string) (string, error) {
+	out, err := m.Sign(&Data{Bytes: []byte(str)})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !out.Encoded {
+		return base64.StdEncoding.EncodeToString(out.Bytes), nil
+	}
+
+	return string(out.Bytes), nil
+}
+
+func init() {
+	Register(MethodNone, func(*Options) (Signer, error) { return none{}, nil })
+}
+
+type none struct{}
+
+func (none) GetMethodName() string {
+	return MethodNone
+}
+
+func (none) Sign(in *Data) (*Data, error) {
+	return in, nil
 }
@@ -0,0 +1,270 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestNew_Vectors(t *testing.T) {
+	const (
+		abc  = "abc"
+		jefe = "what do ya want for nothing?"
+	)
+
+	tests := []struct {
+		method   string
+		opts     *Options
+		data     string
+		expected string
+	}{
+		{MethodMD5, nil, abc, "900150983cd24fb0d6963f7d28e17f72"},
+		{MethodSHA1, nil, abc, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{MethodSHA256, nil, abc, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{
+			MethodSHA512,
+			nil,
+			abc,
+			"ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a" +
+				"2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		},
+		{"SHA256", nil, abc, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{MethodHMACMD5, &Options{Secret: "Jefe"}, jefe, "750c783e6ab0b503eaa86e310a5db738"},
+		{MethodHMACSHA1, &Options{Secret: "Jefe"}, jefe, "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79"},
+		{
+			MethodHMACSHA256,
+			&Options{Secret: "Jefe"},
+			jefe,
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			MethodHMACSHA512,
+			&Options{Secret: "Jefe"},
+			jefe,
+			"164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea250554" +
+				"9758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		{MethodHMAC, &Options{Secret: "Jefe", Hash: MethodSHA1}, jefe, "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79"},
+		{
+			MethodHMAC,
+			&Options{Secret: "Jefe"},
+			jefe,
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			MethodEd25519,
+			&Options{PrivateKey: ed25519PEM(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")},
+			"",
+			"e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e06522490155" +
+				"5fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			signer, err := New(tt.method, tt.opts)
+
+			if err != nil {
+				t.Fatalf("signer creating failed: %v", err)
+			}
+
+			out, err := signer.Sign(&Data{Bytes: []byte(tt.data)})
+
+			if err != nil {
+				t.Fatalf("signing failed: %v", err)
+			}
+
+			if sign := hex.EncodeToString(out.Bytes); sign != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, sign)
+			}
+		})
+	}
+}
+
+func TestNew_Encoders(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0x01}
+
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{MethodBase64, "+/8B"},
+		{MethodBase64Url, "-_8B"},
+		{MethodHexLower, "fbff01"},
+		{MethodHexUpper, "FBFF01"},
+		{MethodNone, "\xfb\xff\x01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			signer, err := New(tt.method, nil)
+
+			if err != nil {
+				t.Fatalf("signer creating failed: %v", err)
+			}
+
+			out, err := signer.Sign(&Data{Bytes: data})
+
+			if err != nil {
+				t.Fatalf("encoding failed: %v", err)
+			}
+
+			if string(out.Bytes) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, out.Bytes)
+			}
+		})
+	}
+}
+
+func TestNew_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+
+	if err != nil {
+		t.Fatalf("rsa key generating failed: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("ecdsa key generating failed: %v", err)
+	}
+
+	data := []byte("abc")
+	digest := hashSum(crypto.SHA256, data)
+
+	tests := []struct {
+		method string
+		key    interface{}
+		verify func(sign []byte) bool
+	}{
+		{
+			MethodRSAPKCS1,
+			rsaKey,
+			func(sign []byte) bool {
+				return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest, sign) == nil
+			},
+		},
+		{
+			MethodRSAPSS,
+			rsaKey,
+			func(sign []byte) bool {
+				return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.SHA256, digest, sign, nil) == nil
+			},
+		},
+		{
+			MethodECDSA,
+			ecKey,
+			func(sign []byte) bool {
+				return ecdsa.VerifyASN1(&ecKey.PublicKey, digest, sign)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			signer, err := New(tt.method, &Options{PrivateKey: privateKeyPEM(t, tt.key)})
+
+			if err != nil {
+				t.Fatalf("signer creating failed: %v", err)
+			}
+
+			out, err := signer.Sign(&Data{Bytes: data})
+
+			if err != nil {
+				t.Fatalf("signing failed: %v", err)
+			}
+
+			if !tt.verify(out.Bytes) {
+				t.Error("signature isn't verified by public key")
+			}
+		})
+	}
+}
+
+func TestNew_JWT(t *testing.T) {
+	signer, err := New(MethodJWT, &Options{Secret: "secret"})
+
+	if err != nil {
+		t.Fatalf("signer creating failed: %v", err)
+	}
+
+	out, err := signer.Sign(&Data{Bytes: []byte(`{"sub":"1"}`)})
+
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+
+	parts := strings.Split(string(out.Bytes), ".")
+
+	if len(parts) != 3 {
+		t.Fatalf("expected token of 3 parts, got %q", out.Bytes)
+	}
+
+	if header, _ := base64.RawURLEncoding.DecodeString(parts[0]); string(header) != `{"alg":"HS256","typ":"JWT"}` {
+		t.Errorf("unexpected token header %s", header)
+	}
+
+	mac := hmac.New(crypto.SHA256.New, []byte("secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if parts[2] != base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Error("token signature isn't verified by secret")
+	}
+
+	if _, err = signer.Sign(&Data{Bytes: []byte("not json")}); err == nil {
+		t.Error("expected error for payload which isn't JSON")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		opts   *Options
+	}{
+		{"unknown method", "crc32", nil},
+		{"hmac without secret", MethodHMACSHA256, nil},
+		{"hmac with unknown hash", MethodHMAC, &Options{Secret: "s", Hash: "crc32"}},
+		{"rsa without key", MethodRSAPKCS1, nil},
+		{"rsa with invalid key", MethodRSAPKCS1, &Options{PrivateKey: "-----BEGIN KEY-----"}},
+		{"jwt with md5", MethodJWT, &Options{Secret: "s", Hash: MethodMD5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.method, tt.opts); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func ed25519PEM(t *testing.T, seed string) string {
+	data, err := hex.DecodeString(seed)
+
+	if err != nil {
+		t.Fatalf("seed decoding failed: %v", err)
+	}
+
+	return privateKeyPEM(t, ed25519.NewKeyFromSeed(data))
+}
+
+func privateKeyPEM(t *testing.T, key interface{}) string {
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		t.Fatalf("private key encoding failed: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}))
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"github.com/sidmal/ianua/internal/entity"
+	"testing"
+)
+
+func TestNewSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *entity.GatewaySecurityHashOpts
+		data     string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "hash encoded to base64 by default",
+			opts:     &entity.GatewaySecurityHashOpts{Algo: "sha256"},
+			data:     "abc",
+			expected: "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=",
+		},
+		{
+			name:     "hash encoded to hex",
+			opts:     &entity.GatewaySecurityHashOpts{Algo: "SHA256", AfterFunc: afterFuncs("hex")},
+			data:     "abc",
+			expected: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{
+			name:     "hash encoded to upper hex",
+			opts:     &entity.GatewaySecurityHashOpts{Algo: "md5", AfterFunc: afterFuncs("hex_upper")},
+			data:     "abc",
+			expected: "900150983CD24FB0D6963F7D28E17F72",
+		},
+		{
+			name:     "hash encoded to base64url",
+			opts:     &entity.GatewaySecurityHashOpts{Algo: "sha1", AfterFunc: afterFuncs("base64url")},
+			data:     "abc",
+			expected: "qZk-NkcGgWq6PiVxeFDCbJzQ2J0=",
+		},
+		{
+			name: "hmac of template without hashing",
+			opts: &entity.GatewaySecurityHashOpts{
+				Algo: "none",
+				AfterFunc: []*entity.GatewaySecurityHashAfterFunc{
+					{Algo: "hmac", Opts: &entity.GatewaySecurityHashAfterFuncOpts{Secret: "Jefe"}},
+					{Algo: "hex"},
+				},
+			},
+			data:     "what do ya want for nothing?",
+			expected: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			name: "hmac with hash algorithm of method",
+			opts: &entity.GatewaySecurityHashOpts{
+				Algo: "none",
+				AfterFunc: []*entity.GatewaySecurityHashAfterFunc{
+					{Algo: "hmac-md5", Opts: &entity.GatewaySecurityHashAfterFuncOpts{Secret: "Jefe"}},
+					{Algo: "hex"},
+				},
+			},
+			data:     "what do ya want for nothing?",
+			expected: "750c783e6ab0b503eaa86e310a5db738",
+		},
+		{
+			name: "hmac without secret",
+			opts: &entity.GatewaySecurityHashOpts{Algo: "none", AfterFunc: afterFuncs("hmac")},
+			err:  true,
+		},
+		{
+			name: "unknown hash algorithm",
+			opts: &entity.GatewaySecurityHashOpts{Algo: "crc32"},
+			err:  true,
+		},
+		{
+			name: "unknown after function",
+			opts: &entity.GatewaySecurityHashOpts{Algo: "sha256", AfterFunc: afterFuncs("base32")},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := newSignature(tt.opts)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			sign, err := chain.GetSignature(tt.data)
+
+			if err != nil {
+				t.Fatalf("signing failed: %v", err)
+			}
+
+			if sign != tt.expected {
+				t.Errorf("expected signature %q, got %q", tt.expected, sign)
+			}
+		})
+	}
+}
+
+func afterFuncs(algos ...string) []*entity.GatewaySecurityHashAfterFunc {
+	funcs := make([]*entity.GatewaySecurityHashAfterFunc, len(algos))
+
+	for i, algo := range algos {
+		funcs[i] = &entity.GatewaySecurityHashAfterFunc{Algo: algo}
+	}
+
+	return funcs
+}
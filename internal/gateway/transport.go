@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"github.com/sidmal/ianua/internal/entity"
 	"go.uber.org/zap"
 	"io/ioutil"
 	"net/http"
@@ -17,28 +18,30 @@ type HttpTransport struct {
 	logger    *zap.Logger
 }
 
-func (m *HttpSettings) getHttpTransport() (http.RoundTripper, error) {
-	transport := http.DefaultTransport
-	transport.(*http.Transport).TLSClientConfig = &tls.Config{
+type requestStartedKey struct{}
+
+func getHttpTransport(opts *entity.HttpClientOpts) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
 		InsecureSkipVerify: true,
 	}
-	transport.(*http.Transport).DisableCompression = true
-	transport.(*http.Transport).IdleConnTimeout = m.ResponseWaitTimeout
-	transport.(*http.Transport).ResponseHeaderTimeout = m.ResponseWaitTimeout
-	transport.(*http.Transport).ExpectContinueTimeout = m.ResponseWaitTimeout
-	transport.(*http.Transport).TLSHandshakeTimeout = m.ResponseWaitTimeout
+	transport.DisableCompression = true
+	transport.IdleConnTimeout = opts.ResponseWaitTimeout
+	transport.ResponseHeaderTimeout = opts.ResponseWaitTimeout
+	transport.ExpectContinueTimeout = opts.ResponseWaitTimeout
+	transport.TLSHandshakeTimeout = opts.ResponseWaitTimeout
 
-	if m.TLS == nil {
+	if opts.TLS == nil {
 		return transport, nil
 	}
 
-	clientKey, err := base64.StdEncoding.DecodeString(m.TLS.ClientKey)
+	clientKey, err := base64.StdEncoding.DecodeString(opts.TLS.ClientKey)
 
 	if err != nil {
 		return nil, err
 	}
 
-	clientCert, err := base64.StdEncoding.DecodeString(m.TLS.ClientCert)
+	clientCert, err := base64.StdEncoding.DecodeString(opts.TLS.ClientCert)
 
 	if err != nil {
 		return nil, err
@@ -50,7 +53,7 @@ func (m *HttpSettings) getHttpTransport() (http.RoundTripper, error) {
 		return nil, err
 	}
 
-	caCert, err := base64.StdEncoding.DecodeString(m.TLS.CaCert)
+	caCert, err := base64.StdEncoding.DecodeString(opts.TLS.CaCert)
 
 	if err != nil {
 		return nil, err
@@ -65,14 +68,14 @@ func (m *HttpSettings) getHttpTransport() (http.RoundTripper, error) {
 		InsecureSkipVerify: true,
 		Renegotiation:      tls.RenegotiateOnceAsClient,
 	}
-	transport.(*http.Transport).TLSClientConfig = tlsConfig
-	transport.(*http.Transport).TLSNextProto = map[string]func(authority string, c *tls.Conn) http.RoundTripper{}
+	transport.TLSClientConfig = tlsConfig
+	transport.TLSNextProto = map[string]func(authority string, c *tls.Conn) http.RoundTripper{}
 
 	return transport, nil
 }
 
 func (m *HttpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	ctx := context.WithValue(req.Context(), map[string]string{"name": "HttpRequest"}, time.Now())
+	ctx := context.WithValue(req.Context(), requestStartedKey{}, time.Now())
 	req = req.WithContext(ctx)
 
 	if m.logger == nil {
@@ -0,0 +1,183 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"github.com/sidmal/ianua/internal/gateway/signature"
+	"github.com/sidmal/ianua/internal/repository"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Timestamp"
+
+	maxResponseBodyLength = 1024
+)
+
+type Options struct {
+	// Interval between checks of notifications queue
+	PollInterval time.Duration
+	// Maximal count of notifications delivered by one check of queue
+	BatchSize int
+	// Maximal count of delivery attempts, notification is marked as failed after it
+	MaxAttempts int
+	// Delay before second delivery attempt, it's doubled for each next attempt
+	InitialBackoff time.Duration
+	// Maximal delay between delivery attempts
+	MaxBackoff time.Duration
+	// Timeout of client response waiting
+	Timeout time.Duration
+}
+
+// Notifier notifies clients about transactions final statuses. Notifications are signed by HMAC-SHA256 with
+// client secret key, signature is calculated by string "<timestamp>.<body>" and encoded to hex.
+type Notifier struct {
+	repository repository.Interface
+	httpClient *http.Client
+	opts       *Options
+	logger     *zap.Logger
+}
+
+func NewNotifier(repository repository.Interface, opts *Options, logger *zap.Logger) *Notifier {
+	notifier := &Notifier{
+		repository: repository,
+		httpClient: &http.Client{Timeout: opts.Timeout},
+		opts:       opts,
+		logger:     logger,
+	}
+	return notifier
+}
+
+// Run delivers queued notifications until context is done
+func (m *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		m.deliverBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBatch delivers up to batch size of notifications, each notification is claimed right before its delivery,
+// so claim lease covers delivery of one notification only
+func (m *Notifier) deliverBatch(ctx context.Context) {
+	lease := m.opts.Timeout + m.opts.PollInterval
+
+	for i := 0; i < m.opts.BatchSize && ctx.Err() == nil; i++ {
+		notifications, err := m.repository.GetNotificationRepository().Claim(ctx, 1, lease)
+
+		if err != nil || len(notifications) == 0 {
+			return
+		}
+
+		if err = m.deliver(ctx, notifications[0]); err != nil {
+			m.logger.Error(
+				"notification delivery saving failed",
+				zap.Error(err),
+				zap.Uint64("notification_id", notifications[0].Id),
+			)
+		}
+	}
+}
+
+func (m *Notifier) deliver(ctx context.Context, notification *repository.Notification) error {
+	notification.Attempts++
+	delivery := &repository.NotificationDelivery{
+		NotificationId: notification.Id,
+		Attempt:        notification.Attempts,
+	}
+	started := time.Now()
+	err := m.send(ctx, notification, delivery)
+	delivery.Duration = time.Since(started)
+
+	switch {
+	case err == nil && delivery.ResponseStatus >= 200 && delivery.ResponseStatus < 300:
+		notification.Status = repository.NotificationStatusDelivered
+	case notification.Attempts >= m.opts.MaxAttempts:
+		notification.Status = repository.NotificationStatusFailed
+	default:
+		notification.NextAttemptAt = time.Now().Add(m.backoff(notification.Attempts))
+	}
+
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	return m.repository.GetNotificationRepository().SaveDelivery(ctx, notification, delivery)
+}
+
+func (m *Notifier) send(
+	ctx context.Context,
+	notification *repository.Notification,
+	delivery *repository.NotificationDelivery,
+) error {
+	client, err := m.repository.GetClientRepository().GetClientById(ctx, notification.ClientId)
+
+	if err != nil {
+		return err
+	}
+
+	signer, err := signature.New(signature.MethodHMACSHA256, &signature.Options{Secret: client.SecretKey})
+
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	hexEncoder, _ := signature.New(signature.MethodHexLower, nil)
+	sign, err := signature.Chain{signer, hexEncoder}.GetSignature(timestamp + "." + string(notification.Payload))
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Url, bytes.NewReader(notification.Payload))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, sign)
+
+	rsp, err := m.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseBodyLength))
+	delivery.ResponseStatus = rsp.StatusCode
+	delivery.ResponseBody = string(body)
+
+	return err
+}
+
+func (m *Notifier) backoff(attempt int) time.Duration {
+	delay := m.opts.InitialBackoff
+
+	for i := 1; i < attempt && delay < m.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > m.opts.MaxBackoff {
+		delay = m.opts.MaxBackoff
+	}
+
+	return delay
+}
@@ -0,0 +1,170 @@
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sidmal/ianua/internal/repository"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// repositoryStub is a repository which provides client and notification repositories only
+type repositoryStub struct {
+	repository.Interface
+	clients       *clientRepositoryStub
+	notifications *notificationRepositoryStub
+}
+
+type clientRepositoryStub struct {
+	repository.MerchantRepositoryInterface
+	client *repository.Client
+}
+
+// notificationRepositoryStub keeps last saved delivery
+type notificationRepositoryStub struct {
+	repository.NotificationRepositoryInterface
+	delivery *repository.NotificationDelivery
+}
+
+func (m *repositoryStub) GetClientRepository() repository.MerchantRepositoryInterface {
+	return m.clients
+}
+
+func (m *repositoryStub) GetNotificationRepository() repository.NotificationRepositoryInterface {
+	return m.notifications
+}
+
+func (m *clientRepositoryStub) GetClientById(_ context.Context, _ uint64) (*repository.Client, error) {
+	return m.client, nil
+}
+
+func (m *notificationRepositoryStub) SaveDelivery(
+	_ context.Context,
+	_ *repository.Notification,
+	delivery *repository.NotificationDelivery,
+) error {
+	m.delivery = delivery
+	return nil
+}
+
+func TestNotifier_deliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		down     bool
+		attempts int
+		expected string
+		retried  bool
+	}{
+		{"delivered", http.StatusOK, false, 0, repository.NotificationStatusDelivered, false},
+		{"client failure retried", http.StatusInternalServerError, false, 0, repository.NotificationStatusPending, true},
+		{"redirect isn't delivery", http.StatusFound, false, 0, repository.NotificationStatusPending, true},
+		{"client unavailable retried", 0, true, 0, repository.NotificationStatusPending, true},
+		{"failed by last attempt", http.StatusInternalServerError, false, 2, repository.NotificationStatusFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"id":1}`)
+			signed := false
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				mac := hmac.New(sha256.New, []byte("secret"))
+				mac.Write([]byte(r.Header.Get(HeaderTimestamp) + "." + string(body)))
+				signed = r.Header.Get(HeaderSignature) == hex.EncodeToString(mac.Sum(nil))
+
+				w.Header().Set("Location", "/")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("ok"))
+			}))
+
+			if tt.down {
+				srv.Close()
+			} else {
+				defer srv.Close()
+			}
+
+			notifications := &notificationRepositoryStub{}
+			repo := &repositoryStub{
+				clients:       &clientRepositoryStub{client: &repository.Client{SecretKey: "secret"}},
+				notifications: notifications,
+			}
+			notifier := NewNotifier(
+				repo,
+				&Options{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour, Timeout: time.Second},
+				zap.NewNop(),
+			)
+			notifier.httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+			notification := &repository.Notification{
+				Url:      srv.URL,
+				Payload:  payload,
+				Status:   repository.NotificationStatusPending,
+				Attempts: tt.attempts,
+			}
+
+			if err := notifier.deliver(context.Background(), notification); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if notification.Status != tt.expected {
+				t.Errorf("expected status %q, got %q", tt.expected, notification.Status)
+			}
+
+			if notification.Attempts != tt.attempts+1 || notifications.delivery.Attempt != tt.attempts+1 {
+				t.Errorf("expected attempt %d saved, got %d", tt.attempts+1, notifications.delivery.Attempt)
+			}
+
+			if retried := !notification.NextAttemptAt.IsZero(); retried != tt.retried {
+				t.Errorf("expected next attempt scheduled %v, got %v", tt.retried, retried)
+			}
+
+			if tt.down {
+				if notifications.delivery.Error == "" {
+					t.Error("expected delivery error saved")
+				}
+
+				return
+			}
+
+			if !signed {
+				t.Error("notification signature isn't valid")
+			}
+
+			if notifications.delivery.ResponseStatus != tt.status || notifications.delivery.ResponseBody != "ok" {
+				t.Errorf("unexpected delivery response %d %q", tt.status, notifications.delivery.ResponseBody)
+			}
+		})
+	}
+}
+
+func TestNotifier_backoff(t *testing.T) {
+	notifier := &Notifier{opts: &Options{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected.String(), func(t *testing.T) {
+			if delay := notifier.backoff(tt.attempt); delay != tt.expected {
+				t.Errorf("expected %s for attempt %d, got %s", tt.expected, tt.attempt, delay)
+			}
+		})
+	}
+}
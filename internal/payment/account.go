@@ -0,0 +1,179 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+const (
+	AccountChecksumLuhn  = "luhn"
+	AccountChecksumMod11 = "mod11"
+	AccountChecksumIban  = "iban"
+
+	ibanMinLength = 15
+	ibanMaxLength = 34
+)
+
+var (
+	accountChecksums = map[string]func(account string) bool{
+		AccountChecksumLuhn:  isLuhnValid,
+		AccountChecksumMod11: isMod11Valid,
+		AccountChecksumIban:  isIbanValid,
+	}
+)
+
+// NormalizeAccount removes whitespaces and leading plus sign from customer's account
+func NormalizeAccount(account string) string {
+	account = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+
+		return r
+	}, account)
+
+	return strings.TrimPrefix(account, "+")
+}
+
+// validateAccount checks normalized customer's account by service account regexp and checksum algorithm
+func validateAccount(service *repository.Service, account string) error {
+	if account == "" {
+		return pkg.ErrorAccountInvalid.SetDetails("account is empty")
+	}
+
+	if service.CacheAccountRegexp != nil && !service.CacheAccountRegexp.MatchString(account) {
+		return pkg.ErrorAccountInvalid.SetDetails("account doesn't match service account format")
+	}
+
+	if service.AccountChecksum == "" {
+		return nil
+	}
+
+	checksum, ok := accountChecksums[service.AccountChecksum]
+
+	if !ok {
+		return pkg.ErrorUnknown
+	}
+
+	if !checksum(account) {
+		return pkg.ErrorAccountInvalid.SetDetails("account checksum is invalid")
+	}
+
+	return nil
+}
+
+// checkAccount sends account check request to provider gateway, account is treated as valid
+// if gateway hasn't check action
+func (m *Processor) checkAccount(
+	ctx context.Context,
+	gw *gateway.Gateway,
+	service *repository.Service,
+	account string,
+) (*gateway.Result, error) {
+	params := map[string]interface{}{
+		ParamAccount:   account,
+		ParamServiceId: service.ExternalId,
+	}
+	result, err := gw.Check(ctx, params)
+
+	if err != nil {
+		m.logger.Error("account check by gateway failed", zap.Error(err), zap.String("handler", gw.Name))
+		return nil, pkg.ErrorUnknown
+	}
+
+	return result, nil
+}
+
+// isLuhnValid checks digits with check digit by Luhn algorithm
+func isLuhnValid(account string) bool {
+	if len(account) < 2 {
+		return false
+	}
+
+	sum := 0
+	double := false
+
+	for i := len(account) - 1; i >= 0; i-- {
+		if account[i] < '0' || account[i] > '9' {
+			return false
+		}
+
+		digit := int(account[i] - '0')
+
+		if double {
+			digit *= 2
+
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// isMod11Valid checks digits with last check digit by modulus 11 algorithm with weights from 2 to 7
+// starting from the rightmost digit before check digit, account with check value 10 is invalid
+func isMod11Valid(account string) bool {
+	if len(account) < 2 {
+		return false
+	}
+
+	sum := 0
+	weight := 2
+
+	for i := len(account) - 2; i >= 0; i-- {
+		if account[i] < '0' || account[i] > '9' {
+			return false
+		}
+
+		sum += int(account[i]-'0') * weight
+
+		if weight++; weight > 7 {
+			weight = 2
+		}
+	}
+
+	last := account[len(account)-1]
+
+	if last < '0' || last > '9' {
+		return false
+	}
+
+	check := (11 - sum%11) % 11
+	return check < 10 && check == int(last-'0')
+}
+
+// isIbanValid checks international bank account number by ISO 13616 modulus 97 algorithm
+func isIbanValid(account string) bool {
+	account = strings.ToUpper(account)
+
+	if len(account) < ibanMinLength || len(account) > ibanMaxLength {
+		return false
+	}
+
+	var digits strings.Builder
+
+	for _, r := range account[4:] + account[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(big.NewInt(int64(r-'A') + 10).String())
+		default:
+			return false
+		}
+	}
+
+	value, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
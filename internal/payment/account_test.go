@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeAccount(t *testing.T) {
+	tests := []struct {
+		account  string
+		expected string
+	}{
+		{"79991234567", "79991234567"},
+		{"+7 999 123 45 67", "79991234567"},
+		{" 7999\t123\n4567 ", "79991234567"},
+		{"++7999", "+7999"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.account, func(t *testing.T) {
+			if account := NormalizeAccount(tt.account); account != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, account)
+			}
+		})
+	}
+}
+
+func TestAccountChecksums(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum string
+		account  string
+		valid    bool
+	}{
+		{"luhn valid", AccountChecksumLuhn, "79927398713", true},
+		{"luhn card number", AccountChecksumLuhn, "4111111111111111", true},
+		{"luhn wrong check digit", AccountChecksumLuhn, "79927398710", false},
+		{"luhn not a digit", AccountChecksumLuhn, "7992739871a", false},
+		{"luhn too short", AccountChecksumLuhn, "0", false},
+		{"mod11 valid", AccountChecksumMod11, "12345674", true},
+		{"mod11 zero check digit", AccountChecksumMod11, "1234560", true},
+		{"mod11 wrong check digit", AccountChecksumMod11, "12345675", false},
+		{"mod11 check value 10", AccountChecksumMod11, "46000510", false},
+		{"mod11 not a digit check", AccountChecksumMod11, "1234567x", false},
+		{"mod11 too short", AccountChecksumMod11, "4", false},
+		{"iban valid", AccountChecksumIban, "GB82WEST12345698765432", true},
+		{"iban lower case", AccountChecksumIban, "gb82west12345698765432", true},
+		{"iban wrong check digits", AccountChecksumIban, "GB83WEST12345698765432", false},
+		{"iban invalid character", AccountChecksumIban, "GB82WEST1234569876543-", false},
+		{"iban too short", AccountChecksumIban, "GB82WEST123", false},
+		{"iban too long", AccountChecksumIban, "GB82WEST12345698765432123456789012", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid := accountChecksums[tt.checksum](tt.account); valid != tt.valid {
+				t.Errorf("expected %v, got %v", tt.valid, valid)
+			}
+		})
+	}
+}
+
+func TestValidateAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		service *repository.Service
+		account string
+		err     string
+	}{
+		{"without rules", &repository.Service{}, "123", ""},
+		{"empty", &repository.Service{}, "", pkg.ErrorAccountInvalid.Code},
+		{"regexp matched", &repository.Service{CacheAccountRegexp: regexp.MustCompile(`^\d{3}$`)}, "123", ""},
+		{
+			"regexp not matched",
+			&repository.Service{CacheAccountRegexp: regexp.MustCompile(`^\d{3}$`)},
+			"1234",
+			pkg.ErrorAccountInvalid.Code,
+		},
+		{"checksum valid", &repository.Service{AccountChecksum: AccountChecksumLuhn}, "79927398713", ""},
+		{
+			"checksum invalid",
+			&repository.Service{AccountChecksum: AccountChecksumLuhn},
+			"79927398710",
+			pkg.ErrorAccountInvalid.Code,
+		},
+		{"checksum unknown", &repository.Service{AccountChecksum: "crc"}, "123", pkg.ErrorUnknown.Code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAccount(tt.service, tt.account)
+
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				return
+			}
+
+			if e, ok := err.(*pkg.Error); !ok || e.Code != tt.err {
+				t.Errorf("expected error %s, got %v", tt.err, err)
+			}
+		})
+	}
+}
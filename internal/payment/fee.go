@@ -0,0 +1,93 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+)
+
+// applyFees calculates client and customer fees of transaction by the most specific tariffs and fills fees fields
+// in all transaction currencies. Fee by tariff is rounded by tariff's rounding mode, its conversions to other
+// currencies are rounded half away from zero. Client's and service's fee percents are used when tariff not found.
+func (m *Processor) applyFees(
+	ctx context.Context,
+	client *repository.Client,
+	service *repository.Service,
+	txn *repository.Transaction,
+) error {
+	fees := m.repository.GetFeeRepository()
+	clientFee, err := fees.GetFee(ctx, repository.FeePayerClient, client.Id, service.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if clientFee == nil {
+		clientFee = &repository.Fee{Percent: client.FeePercent, Rounding: pkg.RoundHalfUp}
+	}
+
+	customerFee, err := fees.GetFee(ctx, repository.FeePayerCustomer, client.Id, service.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if customerFee == nil {
+		customerFee = &repository.Fee{Percent: service.FeePercent, Rounding: pkg.RoundHalfUp}
+	}
+
+	clientFeeInIncome, err := clientFee.Calculate(txn.Income())
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	clientFeeInOutcome, err := clientFeeInIncome.Convert(txn.IncomeToOutcomeRate, txn.OutcomeCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	clientFeeInAccounting, err := clientFeeInIncome.Convert(txn.IncomeToAccountingRate, txn.AccountingCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	customerFeeInOutcome, err := customerFee.Calculate(txn.Outcome())
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	if customerFeeInOutcome.Amount >= txn.OutcomeAmount {
+		return pkg.ErrorAmountInvalid.SetDetails("payment amount doesn't cover customer fee")
+	}
+
+	outcomeToIncomeRate, err := txn.IncomeToOutcomeRate.Inverse()
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	customerFeeInIncome, err := customerFeeInOutcome.Convert(outcomeToIncomeRate, txn.IncomeCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	customerFeeInAccounting, err := customerFeeInOutcome.Convert(txn.OutcomeToAccountingRate, txn.AccountingCurrency)
+
+	if err != nil {
+		return pkg.ErrorAmountInvalid
+	}
+
+	txn.ClientFeeInIncomeCurrency = clientFeeInIncome.Amount
+	txn.ClientFeeInOutcomeCurrency = clientFeeInOutcome.Amount
+	txn.ClientFeeInAccountingCurrency = clientFeeInAccounting.Amount
+	txn.CustomerFeeInIncomeCurrency = customerFeeInIncome.Amount
+	txn.CustomerFeeInOutcomeCurrency = customerFeeInOutcome.Amount
+	txn.CustomerFeeInAccountingCurrency = customerFeeInAccounting.Amount
+
+	return nil
+}
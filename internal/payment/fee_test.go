@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"testing"
+)
+
+// feeRepositoryStub returns tariffs by fee payer
+type feeRepositoryStub map[string]*repository.Fee
+
+func (m feeRepositoryStub) GetFee(_ context.Context, payer string, _, _ uint64) (*repository.Fee, error) {
+	return m[payer], nil
+}
+
+// repositoryStub is a repository which provides fee and limit repositories only
+type repositoryStub struct {
+	repository.Interface
+	fees   repository.FeeRepositoryInterface
+	limits repository.LimitRepositoryInterface
+}
+
+func (m *repositoryStub) GetFeeRepository() repository.FeeRepositoryInterface {
+	return m.fees
+}
+
+func (m *repositoryStub) GetLimitRepository() repository.LimitRepositoryInterface {
+	return m.limits
+}
+
+func TestProcessor_applyFees(t *testing.T) {
+	type fees struct {
+		clientInIncome, clientInOutcome, clientInAccounting       pkg.Amount
+		customerInIncome, customerInOutcome, customerInAccounting pkg.Amount
+	}
+
+	tests := []struct {
+		name     string
+		client   *repository.Fee
+		customer *repository.Fee
+		expected *fees
+		err      string
+	}{
+		{
+			name:     "client and service fee percents without tariffs",
+			expected: &fees{100, 90, 100, 200, 180, 200},
+		},
+		{
+			name: "tier with the greatest lower bound",
+			client: &repository.Fee{
+				Percent: mustParsePercent(t, "2"),
+				Tiers: repository.FeeTiers{
+					{From: 0, Percent: mustParsePercent(t, "1")},
+					{From: 5000, Percent: mustParsePercent(t, "0.5"), Fixed: 10},
+					{From: 20000, Percent: mustParsePercent(t, "0.1")},
+				},
+				Rounding: pkg.RoundHalfUp,
+			},
+			customer: &repository.Fee{},
+			expected: &fees{60, 54, 60, 0, 0, 0},
+		},
+		{
+			name:     "minimal and maximal fee",
+			client:   &repository.Fee{Percent: mustParsePercent(t, "1"), Min: 150, Rounding: pkg.RoundHalfUp},
+			customer: &repository.Fee{Percent: mustParsePercent(t, "10"), Max: 500, Rounding: pkg.RoundHalfUp},
+			expected: &fees{150, 135, 150, 556, 500, 556},
+		},
+		{
+			name:     "tariff rounding mode",
+			client:   &repository.Fee{Percent: mustParsePercent(t, "0.335"), Rounding: pkg.RoundDown},
+			customer: &repository.Fee{Percent: mustParsePercent(t, "0.335"), Rounding: pkg.RoundUp},
+			expected: &fees{33, 30, 33, 34, 31, 34},
+		},
+		{
+			name:     "customer fee covers payment amount",
+			customer: &repository.Fee{Fixed: 9000},
+			err:      pkg.ErrorAmountInvalid.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := feeRepositoryStub{}
+
+			if tt.client != nil {
+				stub[repository.FeePayerClient] = tt.client
+			}
+
+			if tt.customer != nil {
+				stub[repository.FeePayerCustomer] = tt.customer
+			}
+
+			processor := &Processor{repository: &repositoryStub{fees: stub}}
+			client := &repository.Client{FeePercent: mustParsePercent(t, "1")}
+			service := &repository.Service{FeePercent: mustParsePercent(t, "2")}
+			txn := &repository.Transaction{
+				IncomeAmount:            10000,
+				IncomeCurrency:          "USD",
+				OutcomeAmount:           9000,
+				OutcomeCurrency:         "EUR",
+				AccountingAmount:        10000,
+				AccountingCurrency:      "USD",
+				IncomeToOutcomeRate:     mustParseRate(t, "0.9"),
+				IncomeToAccountingRate:  pkg.RateOne,
+				OutcomeToAccountingRate: mustParseRate(t, "1.111111"),
+			}
+			err := processor.applyFees(context.Background(), client, service, txn)
+
+			if tt.err != "" {
+				if e, ok := err.(*pkg.Error); !ok || e.Code != tt.err {
+					t.Fatalf("expected error %s, got %v", tt.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			calculated := &fees{
+				txn.ClientFeeInIncomeCurrency,
+				txn.ClientFeeInOutcomeCurrency,
+				txn.ClientFeeInAccountingCurrency,
+				txn.CustomerFeeInIncomeCurrency,
+				txn.CustomerFeeInOutcomeCurrency,
+				txn.CustomerFeeInAccountingCurrency,
+			}
+
+			if *calculated != *tt.expected {
+				t.Errorf("expected fees %+v, got %+v", *tt.expected, *calculated)
+			}
+		})
+	}
+}
+
+func mustParsePercent(t *testing.T, value string) pkg.Percent {
+	percent, err := pkg.ParsePercent(value)
+
+	if err != nil {
+		t.Fatalf("percent %q parsing failed: %v", value, err)
+	}
+
+	return percent
+}
+
+func mustParseRate(t *testing.T, value string) pkg.Rate {
+	rate, err := pkg.ParseRate(value)
+
+	if err != nil {
+		t.Fatalf("rate %q parsing failed: %v", value, err)
+	}
+
+	return rate
+}
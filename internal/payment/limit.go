@@ -0,0 +1,125 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"time"
+)
+
+// checkLimits checks transaction outcome amount by service amount limits overridden by client limits and returns
+// daily and monthly limits of payments amount into customer's account, they're checked on transaction creation
+func (m *Processor) checkLimits(
+	ctx context.Context,
+	client *repository.Client,
+	service *repository.Service,
+	txn *repository.Transaction,
+) ([]*repository.VelocityLimit, error) {
+	minAmount, maxAmount := service.MinAmount, service.MaxAmount
+	dailyLimit, monthlyLimit := service.DailyLimit, service.MonthlyLimit
+	override, err := m.repository.GetLimitRepository().GetClientLimit(ctx, client.Id, service.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if override != nil {
+		if override.MinAmount != nil {
+			minAmount = *override.MinAmount
+		}
+
+		if override.MaxAmount != nil {
+			maxAmount = *override.MaxAmount
+		}
+
+		if override.DailyLimit != nil {
+			dailyLimit = *override.DailyLimit
+		}
+
+		if override.MonthlyLimit != nil {
+			monthlyLimit = *override.MonthlyLimit
+		}
+	}
+
+	if txn.OutcomeAmount < minAmount || (maxAmount > 0 && txn.OutcomeAmount > maxAmount) {
+		return nil, pkg.ErrorAmountOutOfLimits.SetLimits(amountLimits(txn, minAmount, maxAmount, maxAmount > 0))
+	}
+
+	current := time.Now().UTC()
+	periods := []*repository.VelocityLimit{
+		{
+			Name:  "daily",
+			Limit: dailyLimit,
+			Since: time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:  "monthly",
+			Limit: monthlyLimit,
+			Since: time.Date(current.Year(), current.Month(), 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	velocity := make([]*repository.VelocityLimit, 0, len(periods))
+
+	for _, period := range periods {
+		if period.Limit > 0 {
+			velocity = append(velocity, period)
+		}
+	}
+
+	return velocity, nil
+}
+
+// velocityLimitError returns error about exceeded limit of payments amount into customer's account with amount
+// which still can be paid into account
+func velocityLimitError(txn *repository.Transaction, e *repository.VelocityLimitError) *pkg.Error {
+	limit := pkg.NewMoney(e.Limit.Limit, txn.OutcomeCurrency)
+
+	return pkg.ErrorAmountVelocityExceeded.
+		SetDetails(e.Limit.Name + " limit " + limit.String() + " exceeded").
+		SetLimits(amountLimits(txn, 0, e.Available, true))
+}
+
+// amountLimits returns range of allowed amount in provider's currency and range converted to client's currency
+// by inverse transaction conversion rate, maximal amount is omitted if range isn't bounded. Client's range is omitted
+// if it can't be converted to client's currency.
+func amountLimits(txn *repository.Transaction, min, max pkg.Amount, bounded bool) *pkg.AmountLimits {
+	rate, rateErr := txn.IncomeToOutcomeRate.Inverse()
+	limits := &pkg.AmountLimits{
+		Provider: &pkg.AmountRange{
+			Min:      min.Decimal(txn.OutcomeCurrency),
+			Currency: txn.OutcomeCurrency,
+		},
+	}
+
+	if bounded {
+		limits.Provider.Max = max.Decimal(txn.OutcomeCurrency)
+	}
+
+	if rateErr != nil {
+		return limits
+	}
+
+	clientMin, err := pkg.NewMoney(min, txn.OutcomeCurrency).Convert(rate, txn.IncomeCurrency)
+
+	if err != nil {
+		return limits
+	}
+
+	limits.Client = &pkg.AmountRange{
+		Min:      clientMin.Decimal(),
+		Currency: txn.IncomeCurrency,
+	}
+
+	if bounded {
+		clientMax, err := pkg.NewMoney(max, txn.OutcomeCurrency).Convert(rate, txn.IncomeCurrency)
+
+		if err != nil {
+			limits.Client = nil
+			return limits
+		}
+
+		limits.Client.Max = clientMax.Decimal()
+	}
+
+	return limits
+}
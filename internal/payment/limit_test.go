@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"testing"
+)
+
+// limitRepositoryStub returns the same client limit for any service
+type limitRepositoryStub struct {
+	limit *repository.ClientLimit
+}
+
+func (m *limitRepositoryStub) GetClientLimit(context.Context, uint64, uint64) (*repository.ClientLimit, error) {
+	return m.limit, nil
+}
+
+func TestProcessor_checkLimits(t *testing.T) {
+	amount := func(value pkg.Amount) *pkg.Amount {
+		return &value
+	}
+
+	service := &repository.Service{MinAmount: 1000, MaxAmount: 10000, DailyLimit: 50000, MonthlyLimit: 100000}
+
+	tests := []struct {
+		name     string
+		override *repository.ClientLimit
+		outcome  pkg.Amount
+		velocity []pkg.Amount
+		err      string
+	}{
+		{
+			name:     "service limits",
+			outcome:  5000,
+			velocity: []pkg.Amount{50000, 100000},
+		},
+		{
+			name:    "less than service minimal amount",
+			outcome: 999,
+			err:     pkg.ErrorAmountOutOfLimits.Code,
+		},
+		{
+			name:    "greater than service maximal amount",
+			outcome: 10001,
+			err:     pkg.ErrorAmountOutOfLimits.Code,
+		},
+		{
+			name:     "service limits used for limits which aren't overridden",
+			override: &repository.ClientLimit{MaxAmount: amount(20000)},
+			outcome:  15000,
+			velocity: []pkg.Amount{50000, 100000},
+		},
+		{
+			name:     "zero limits override service limits",
+			override: &repository.ClientLimit{MinAmount: amount(0), MaxAmount: amount(0), DailyLimit: amount(0)},
+			outcome:  500000,
+			velocity: []pkg.Amount{100000},
+		},
+		{
+			name:     "client limits",
+			override: &repository.ClientLimit{MinAmount: amount(100), DailyLimit: amount(200), MonthlyLimit: amount(0)},
+			outcome:  100,
+			velocity: []pkg.Amount{200},
+		},
+		{
+			name:     "greater than client maximal amount",
+			override: &repository.ClientLimit{MaxAmount: amount(2000)},
+			outcome:  2001,
+			err:      pkg.ErrorAmountOutOfLimits.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &Processor{repository: &repositoryStub{limits: &limitRepositoryStub{limit: tt.override}}}
+			txn := &repository.Transaction{
+				IncomeAmount:        tt.outcome,
+				IncomeCurrency:      "USD",
+				OutcomeAmount:       tt.outcome,
+				OutcomeCurrency:     "USD",
+				IncomeToOutcomeRate: pkg.RateOne,
+			}
+			velocity, err := processor.checkLimits(context.Background(), &repository.Client{}, service, txn)
+
+			if tt.err != "" {
+				if e, ok := err.(*pkg.Error); !ok || e.Code != tt.err {
+					t.Fatalf("expected error %s, got %v", tt.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if len(velocity) != len(tt.velocity) {
+				t.Fatalf("expected %d velocity limits, got %d", len(tt.velocity), len(velocity))
+			}
+
+			for i, limit := range velocity {
+				if limit.Limit != tt.velocity[i] {
+					t.Errorf("expected velocity limit %d, got %d", tt.velocity[i], limit.Limit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/sidmal/ianua/internal/conversion"
+	"github.com/sidmal/ianua/internal/entity"
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/repository"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	ParamTransactionId = "transaction_id"
+	ParamAccount       = "account"
+	ParamAmount        = "amount"
+	ParamCurrency      = "currency"
+	ParamServiceId     = "service_id"
+	ParamDescription   = "description"
+	ParamCreatedAt     = "created_at"
+	ParamProviderTxnId = "provider_txn_id"
+
+	// Maximal count of attempts to save payment result when transaction is changed concurrently
+	maxSaveResultAttempts = 3
+)
+
+// Processor creates transactions by clients requests and processes them by providers gateways
+type Processor struct {
+	repository repository.Interface
+	gateways   gateway.Gateways
+	converter  *conversion.Converter
+	logger     *zap.Logger
+	// Maximal time of payment processing by gateway
+	processTimeout time.Duration
+	// Payments which are processed by gateways now
+	processing sync.WaitGroup
+}
+
+func NewProcessor(
+	repository repository.Interface,
+	gateways gateway.Gateways,
+	converter *conversion.Converter,
+	processTimeout time.Duration,
+	logger *zap.Logger,
+) *Processor {
+	processor := &Processor{
+		repository:     repository,
+		gateways:       gateways,
+		converter:      converter,
+		logger:         logger,
+		processTimeout: processTimeout,
+	}
+	return processor
+}
+
+// Create creates transaction by client payment request and starts its processing by provider gateway.
+// Repeated request with same order identifier returns transaction created by first request, flag of transaction
+// creation is returned together with transaction.
+func (m *Processor) Create(
+	ctx context.Context,
+	client *repository.Client,
+	req *pkg.PaymentRequest,
+) (*repository.Transaction, bool, error) {
+	requestHash, err := getRequestHash(req)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := m.repository.GetTransactionRepository().GetTransactionByClientTxnId(ctx, client.Id, req.OrderId)
+
+	if err != nil {
+		return nil, false, pkg.ErrorUnknown
+	}
+
+	if existing != nil {
+		if existing.RequestHash != requestHash {
+			return nil, false, pkg.ErrorTransactionConflict
+		}
+
+		return existing, false, nil
+	}
+
+	service, err := m.repository.GetProjectRepository().GetService(ctx, req.ProjectId)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	gw, err := m.gateways.Get(service.Provider.Handler)
+
+	if err != nil {
+		return nil, false, pkg.ErrorGatewayNotFound
+	}
+
+	account := NormalizeAccount(req.Account)
+
+	if err = validateAccount(service, account); err != nil {
+		return nil, false, err
+	}
+
+	amount, err := req.Amount.Amount(client.Currency)
+
+	if err != nil || amount <= 0 {
+		return nil, false, pkg.ErrorAmountInvalid
+	}
+
+	clientTxnId := req.OrderId
+	txn := &repository.Transaction{
+		ClientId:          client.Id,
+		ClientName:        client.Name,
+		ProviderId:        service.Provider.Id,
+		ProviderName:      service.Provider.Name,
+		ServiceId:         service.Id,
+		ServiceName:       service.Name,
+		ProviderHandlerId: service.Provider.Handler,
+		ClientTxnId:       &clientTxnId,
+		RequestHash:       requestHash,
+		Account:           account,
+		Metadata:          req.Metadata,
+		IncomeAmount:      amount,
+		IncomeCurrency:    client.Currency,
+		OutcomeCurrency:   service.Provider.Currency,
+		Status:            repository.TransactionStatusNew,
+	}
+
+	if err = m.converter.Apply(ctx, client, txn); err != nil {
+		return nil, false, err
+	}
+
+	velocity, err := m.checkLimits(ctx, client, service, txn)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = m.applyFees(ctx, client, service, txn); err != nil {
+		return nil, false, err
+	}
+
+	// account is checked in provider billing system after local checks, so provider isn't requested for payments
+	// which would be refused anyway. Result of passed pre-check is used in payment flow instead of second check.
+	var check *gateway.Result
+
+	if service.AccountPreCheck {
+		if check, err = m.checkAccount(ctx, gw, service, account); err != nil {
+			return nil, false, err
+		}
+
+		if check != nil && check.Status == gateway.StatusRejected {
+			return nil, false, pkg.ErrorAccountInvalid.SetDetails(check.RejectReason)
+		}
+	}
+
+	saved, created, err := m.repository.GetTransactionRepository().Create(ctx, txn, velocity)
+
+	if e, ok := err.(*repository.VelocityLimitError); ok {
+		return nil, false, velocityLimitError(txn, e)
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	txn = saved
+
+	if created {
+		m.processing.Add(1)
+
+		go func() {
+			defer m.processing.Done()
+			m.process(gw, service.ExternalId, txn, check)
+		}()
+	}
+
+	return txn, created, nil
+}
+
+// Status returns transaction by client transaction identifier
+func (m *Processor) Status(
+	ctx context.Context,
+	client *repository.Client,
+	req *pkg.StatusRequest,
+) (*repository.Transaction, error) {
+	txn, err := m.repository.GetTransactionRepository().GetTransactionByClientTxnId(ctx, client.Id, req.OrderId)
+
+	if err != nil {
+		return nil, pkg.ErrorUnknown
+	}
+
+	if txn == nil {
+		return nil, pkg.ErrorTransactionNotFound
+	}
+
+	return txn, nil
+}
+
+// CheckAccount checks customer's account by service account format and in provider billing system
+// if provider gateway supports it
+func (m *Processor) CheckAccount(ctx context.Context, req *pkg.BaseRequest) (*pkg.AccountCheckResponse, error) {
+	service, err := m.repository.GetProjectRepository().GetService(ctx, req.ProjectId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gw, err := m.gateways.Get(service.Provider.Handler)
+
+	if err != nil {
+		return nil, pkg.ErrorGatewayNotFound
+	}
+
+	account := NormalizeAccount(req.Account)
+
+	if err = validateAccount(service, account); err != nil {
+		return nil, err
+	}
+
+	result, err := m.checkAccount(ctx, gw, service, account)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pkg.AccountCheckResponse{
+		Account: account,
+		Valid:   true,
+	}
+
+	if result != nil {
+		rsp.Valid = result.Status != gateway.StatusRejected
+		rsp.Details = result.Fields
+	}
+
+	return rsp, nil
+}
+
+// process sends payment to provider gateway and saves payment result to transaction, account isn't checked again
+// if result of account pre-check is received
+func (m *Processor) process(
+	gw *gateway.Gateway,
+	serviceExternalId string,
+	txn *repository.Transaction,
+	check *gateway.Result,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.processTimeout)
+	defer cancel()
+
+	logger := m.logger.With(zap.Uint64("transaction_id", txn.Id), zap.String("handler", gw.Name))
+	transactions := m.repository.GetTransactionRepository()
+
+	if _, ok := gw.GetActionByRole(entity.MethodRoleCheck); ok && check == nil {
+		if err := transactions.Transit(ctx, txn, repository.TransactionStatusPendingCheck, ""); err != nil {
+			logger.Error("transaction status changing failed", zap.Error(err))
+			return
+		}
+	}
+
+	result, err := gw.Pay(ctx, transactionParams(txn, serviceExternalId), check)
+
+	if err != nil {
+		// payment result is unknown, so transaction must be checked by operator
+		logger.Error("payment processing by gateway failed", zap.Error(err))
+
+		if err = transactions.Transit(ctx, txn, repository.TransactionStatusManualReview, err.Error()); err != nil {
+			logger.Error("transaction status changing failed", zap.Error(err))
+		}
+
+		return
+	}
+
+	if err = m.saveResult(ctx, txn, result); err != nil {
+		logger.Error("payment result saving failed", zap.Error(err), zap.String("status", result.Status))
+	}
+}
+
+// saveResult changes transaction status by payment result received from provider gateway. Transaction is read
+// again and change is retried if transaction was changed concurrently, i.e. by provider callback, change isn't
+// needed if transaction already has status of payment result or it's already finished with in progress result.
+func (m *Processor) saveResult(ctx context.Context, txn *repository.Transaction, result *gateway.Result) error {
+	transactions := m.repository.GetTransactionRepository()
+	var err error
+
+	for attempt := 0; attempt < maxSaveResultAttempts; attempt++ {
+		switch result.Status {
+		case gateway.StatusCompleted:
+			err = transactions.Complete(ctx, txn, result.ProviderTxnId)
+		case gateway.StatusRejected:
+			err = transactions.Reject(ctx, txn, result.ProviderTxnId, result.RejectReason)
+		case gateway.StatusManualReview:
+			err = transactions.Transit(ctx, txn, repository.TransactionStatusManualReview, result.RejectReason)
+		default:
+			err = transactions.SetInProgress(ctx, txn, result.ProviderTxnId)
+		}
+
+		if err != pkg.ErrorTransactionVersionConflict {
+			return err
+		}
+
+		current, err := transactions.GetTransactionById(ctx, txn.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			return pkg.ErrorTransactionNotFound
+		}
+
+		*txn = *current
+
+		if txn.Status == result.Status || result.Status == gateway.StatusInProgress && repository.IsFinalStatus(txn.Status) {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// getRequestHash returns hash of payment request to compare repeated requests with same order identifier
+func getRequestHash(req *pkg.PaymentRequest) (string, error) {
+	data, err := json.Marshal(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// transactionParams returns parameters of gateway request by transaction, provider is paid by payment amount reduced
+// by customer fee as provider payable is credited by this amount
+func transactionParams(txn *repository.Transaction, serviceExternalId string) map[string]interface{} {
+	params := map[string]interface{}{
+		ParamTransactionId: txn.Uuid,
+		ParamAccount:       txn.Account,
+		ParamAmount:        (txn.OutcomeAmount - txn.CustomerFeeInOutcomeCurrency).Format(txn.OutcomeCurrency),
+		ParamCurrency:      txn.OutcomeCurrency,
+		ParamServiceId:     serviceExternalId,
+		ParamDescription:   txn.Description,
+		ParamCreatedAt:     txn.CreatedAt.Format(time.RFC3339),
+	}
+
+	if txn.ProviderTxnId != nil {
+		params[ParamProviderTxnId] = *txn.ProviderTxnId
+	}
+
+	return params
+}
@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/repository"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// Maximal count of transactions reconciled by one run
+	reconcileBatchSize = 100
+	// The reason of sending transaction to manual review when its payment status is unknown
+	reconcileReason = "transaction processing was interrupted"
+)
+
+// Wait waits until payments which are processed by gateways now are finished or context is done
+func (m *Processor) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		m.processing.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunReconciler reconciles stale transactions on start and then by interval until context is done
+func (m *Processor) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.Reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile finishes transactions which processing was interrupted, i.e. by restart of service, so funds reserved
+// for them aren't locked forever. Transaction is stale if it wasn't sent to provider or its account check wasn't
+// finished during processing timeout. Its status is requested from provider gateway, transaction is sent to manual
+// review if provider status of payment is unknown.
+func (m *Processor) Reconcile(ctx context.Context) {
+	stale, err := m.repository.GetTransactionRepository().GetStaleTransactions(
+		ctx,
+		time.Now().Add(-m.processTimeout),
+		reconcileBatchSize,
+	)
+
+	if err != nil {
+		return
+	}
+
+	for _, txn := range stale {
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.reconcile(ctx, txn)
+	}
+}
+
+func (m *Processor) reconcile(ctx context.Context, stale *repository.StaleTransaction) {
+	txn := &stale.Transaction
+	logger := m.logger.With(zap.Uint64("transaction_id", txn.Id), zap.String("handler", txn.ProviderHandlerId))
+	gw, err := m.gateways.Get(txn.ProviderHandlerId)
+
+	if err == nil {
+		statusCtx, cancel := context.WithTimeout(ctx, m.processTimeout)
+		result, err := gw.Status(statusCtx, transactionParams(txn, stale.ServiceExternalId))
+		cancel()
+
+		if err != nil {
+			logger.Warn("stale transaction status request failed", zap.Error(err))
+		}
+
+		if err == nil && result != nil && isKnownStatus(result.Status) {
+			if err = m.saveResult(ctx, txn, result); err != nil {
+				logger.Error("stale transaction result saving failed", zap.Error(err))
+			}
+
+			return
+		}
+	}
+
+	err = m.repository.GetTransactionRepository().Transit(
+		ctx,
+		txn,
+		repository.TransactionStatusManualReview,
+		reconcileReason,
+	)
+
+	if err != nil {
+		logger.Error("stale transaction status changing failed", zap.Error(err))
+	}
+}
+
+func isKnownStatus(status string) bool {
+	return status == gateway.StatusInProgress || status == gateway.StatusCompleted ||
+		status == gateway.StatusRejected || status == gateway.StatusManualReview
+}
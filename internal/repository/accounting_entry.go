@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	AccountClientBalance      = "client_balance"
+	AccountProviderPayable    = "provider_payable"
+	AccountClientFeeRevenue   = "client_fee_revenue"
+	AccountCustomerFeeRevenue = "customer_fee_revenue"
+	AccountFxGainLoss         = "fx_gain_loss"
+)
+
+// AccountingEntry is an entry of double-entry ledger. Each entry is expressed in currency of account
+// and in system accounting currency, all entries of transaction net to zero in accounting currency.
+type AccountingEntry struct {
+	Id uint64 `db:"id"`
+	// The transaction unique identifier in billing system.
+	TransactionId uint64 `db:"transaction_id"`
+	// The ledger account name.
+	Account string `db:"account"`
+	// The client or provider identifier which owns account, zero for system accounts.
+	OwnerId uint64 `db:"owner_id"`
+	// The debit amount in account currency.
+	Debit pkg.Amount `db:"debit"`
+	// The credit amount in account currency.
+	Credit pkg.Amount `db:"credit"`
+	// The account currency.
+	Currency string `db:"currency"`
+	// The debit amount in system accounting currency.
+	AccountingDebit pkg.Amount `db:"accounting_debit"`
+	// The credit amount in system accounting currency.
+	AccountingCredit pkg.Amount `db:"accounting_credit"`
+	// The system accounting currency.
+	AccountingCurrency string    `db:"accounting_currency"`
+	CreatedAt          time.Time `db:"created_at"`
+}
+
+type accountingEntryRepository repository
+
+func newAccountingEntryRepository(db *sqlx.DB, logger *zap.Logger) AccountingEntryRepositoryInterface {
+	repository := &accountingEntryRepository{
+		db:     db,
+		logger: logger,
+	}
+	return repository
+}
+
+func (m *accountingEntryRepository) GetEntriesByTransactionId(
+	ctx context.Context,
+	txnId uint64,
+) ([]*AccountingEntry, error) {
+	query := "SELECT id, transaction_id, account, owner_id, debit, credit, currency, accounting_debit, " +
+		"accounting_credit, accounting_currency, created_at FROM accounting_entries WHERE transaction_id = $1 ORDER BY id"
+	args := []interface{}{txnId}
+	entries := make([]*AccountingEntry, 0)
+
+	if err := m.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return entries, nil
+}
+
+// GetAccountBalance returns account balance in account currency as difference between debit and credit
+// of all account entries created before received time
+func (m *accountingEntryRepository) GetAccountBalance(
+	ctx context.Context,
+	account string,
+	ownerId uint64,
+	currency string,
+	at time.Time,
+) (pkg.Amount, error) {
+	query := "SELECT COALESCE(SUM(debit) - SUM(credit), 0)::BIGINT FROM accounting_entries " +
+		"WHERE account = $1 AND owner_id = $2 AND currency = $3 AND created_at <= $4"
+	args := []interface{}{account, ownerId, currency, at}
+	balance := pkg.Amount(0)
+
+	if err := m.db.GetContext(ctx, &balance, query, args...); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return 0, pkg.ErrorUnknown
+	}
+
+	return balance, nil
+}
+
+// GetUnbalancedTransactions returns identifiers of transactions which entries don't net to zero
+// in accounting currency, empty result means that ledger is consistent
+func (m *accountingEntryRepository) GetUnbalancedTransactions(ctx context.Context) ([]uint64, error) {
+	query := "SELECT transaction_id FROM accounting_entries GROUP BY transaction_id " +
+		"HAVING SUM(accounting_debit) <> SUM(accounting_credit) ORDER BY transaction_id"
+	args := []interface{}{}
+	ids := make([]uint64, 0)
+
+	if err := m.db.SelectContext(ctx, &ids, query, args...); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return ids, nil
+}
+
+// NewTransactionEntries returns balanced entries of paid transaction: client balance is debited by payment amount
+// with client fee, provider payable is credited by payment amount reduced by customer fee, fee revenue accounts are
+// credited by fees and difference of conversions to accounting currency is posted to FX gain/loss account.
+func NewTransactionEntries(txn *Transaction) ([]*AccountingEntry, error) {
+	payable, err := txn.Outcome().Sub(txn.CustomerFee())
+
+	if err != nil {
+		return nil, err
+	}
+
+	accountingPayable, err := payable.Convert(txn.OutcomeToAccountingRate, txn.AccountingCurrency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*AccountingEntry{
+		{
+			Account:         AccountClientBalance,
+			OwnerId:         txn.ClientId,
+			Debit:           txn.IncomeAmount + txn.ClientFeeInIncomeCurrency,
+			Currency:        txn.IncomeCurrency,
+			AccountingDebit: txn.AccountingAmount + txn.ClientFeeInAccountingCurrency,
+		},
+		{
+			Account:          AccountClientFeeRevenue,
+			Credit:           txn.ClientFeeInIncomeCurrency,
+			Currency:         txn.IncomeCurrency,
+			AccountingCredit: txn.ClientFeeInAccountingCurrency,
+		},
+		{
+			Account:          AccountCustomerFeeRevenue,
+			Credit:           txn.CustomerFeeInOutcomeCurrency,
+			Currency:         txn.OutcomeCurrency,
+			AccountingCredit: txn.CustomerFeeInAccountingCurrency,
+		},
+		{
+			Account:          AccountProviderPayable,
+			OwnerId:          txn.ProviderId,
+			Credit:           payable.Amount,
+			Currency:         payable.Currency,
+			AccountingCredit: accountingPayable.Amount,
+		},
+	}
+
+	diff := pkg.Amount(0)
+
+	for _, entry := range entries {
+		entry.TransactionId = txn.Id
+		entry.AccountingCurrency = txn.AccountingCurrency
+		diff += entry.AccountingDebit - entry.AccountingCredit
+	}
+
+	fx := &AccountingEntry{
+		TransactionId:      txn.Id,
+		Account:            AccountFxGainLoss,
+		Currency:           txn.AccountingCurrency,
+		AccountingCurrency: txn.AccountingCurrency,
+	}
+
+	if diff > 0 {
+		fx.Credit, fx.AccountingCredit = diff, diff
+	} else {
+		fx.Debit, fx.AccountingDebit = -diff, -diff
+	}
+
+	return append(entries, fx), nil
+}
+
+// ReverseEntries returns entries which cancel received entries, i.e. for refunded transaction
+func ReverseEntries(entries []*AccountingEntry) []*AccountingEntry {
+	reversed := make([]*AccountingEntry, len(entries))
+
+	for i, entry := range entries {
+		e := *entry
+		e.Id = 0
+		e.Debit, e.Credit = entry.Credit, entry.Debit
+		e.AccountingDebit, e.AccountingCredit = entry.AccountingCredit, entry.AccountingDebit
+		reversed[i] = &e
+	}
+
+	return reversed
+}
+
+func postAccountingEntries(ctx context.Context, tx *sqlx.Tx, entries []*AccountingEntry, at time.Time) error {
+	query := "INSERT INTO accounting_entries (transaction_id, account, owner_id, debit, credit, currency, " +
+		"accounting_debit, accounting_credit, accounting_currency, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+
+	for _, entry := range entries {
+		if entry.Debit == 0 && entry.Credit == 0 && entry.AccountingDebit == 0 && entry.AccountingCredit == 0 {
+			continue
+		}
+
+		entry.CreatedAt = at
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			entry.TransactionId,
+			entry.Account,
+			entry.OwnerId,
+			entry.Debit,
+			entry.Credit,
+			entry.Currency,
+			entry.AccountingDebit,
+			entry.AccountingCredit,
+			entry.AccountingCurrency,
+			entry.CreatedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"github.com/sidmal/ianua/internal/cache"
+)
+
+// CourseCache is a cache of current courses by currencies pair
+type CourseCache struct {
+	*cache.Cache
+}
+
+// ClientCache is a cache of clients by uuid and by identifier
+type ClientCache struct {
+	*cache.Cache
+}
+
+// ServiceCache is a cache of services by uuid
+type ServiceCache struct {
+	*cache.Cache
+}
+
+// ProviderCache is a cache of providers by uuid
+type ProviderCache struct {
+	*cache.Cache
+}
+
+func (m *CourseCache) Get(key string) (*Course, bool) {
+	value, ok := m.Cache.Get(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*Course), true
+}
+
+func (m *CourseCache) Set(key string, course *Course) {
+	m.Cache.Set(key, course)
+}
+
+func (m *CourseCache) GetOrLoad(
+	ctx context.Context,
+	key string,
+	loader func(ctx context.Context) (*Course, error),
+) (*Course, error) {
+	value, err := m.Cache.GetOrLoad(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Course), nil
+}
+
+func (m *ClientCache) Get(key string) (*Client, bool) {
+	value, ok := m.Cache.Get(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*Client), true
+}
+
+func (m *ClientCache) Set(key string, client *Client) {
+	m.Cache.Set(key, client)
+}
+
+func (m *ClientCache) GetOrLoad(
+	ctx context.Context,
+	key string,
+	loader func(ctx context.Context) (*Client, error),
+) (*Client, error) {
+	value, err := m.Cache.GetOrLoad(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Client), nil
+}
+
+func (m *ServiceCache) Get(key string) (*Service, bool) {
+	value, ok := m.Cache.Get(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*Service), true
+}
+
+func (m *ServiceCache) Set(key string, service *Service) {
+	m.Cache.Set(key, service)
+}
+
+func (m *ServiceCache) GetOrLoad(
+	ctx context.Context,
+	key string,
+	loader func(ctx context.Context) (*Service, error),
+) (*Service, error) {
+	value, err := m.Cache.GetOrLoad(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Service), nil
+}
+
+func (m *ProviderCache) Get(key string) (*Provider, bool) {
+	value, ok := m.Cache.Get(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*Provider), true
+}
+
+func (m *ProviderCache) Set(key string, provider *Provider) {
+	m.Cache.Set(key, provider)
+}
+
+func (m *ProviderCache) GetOrLoad(
+	ctx context.Context,
+	key string,
+	loader func(ctx context.Context) (*Provider, error),
+) (*Provider, error) {
+	value, err := m.Cache.GetOrLoad(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Provider), nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/ianua/internal/cache"
+	"testing"
+	"time"
+)
+
+func TestCourseCache_GetOrLoad(t *testing.T) {
+	courses := &CourseCache{Cache: cache.New(&cache.Options{Lifetime: time.Minute})}
+	defer courses.Close()
+
+	if _, ok := courses.Get("USDEUR"); ok {
+		t.Fatal("expected course missed in empty cache")
+	}
+
+	loads := 0
+	loader := func(context.Context) (*Course, error) {
+		loads++
+		return &Course{From: "USD", To: "EUR"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		course, err := courses.GetOrLoad(context.Background(), "USDEUR", loader)
+
+		if err != nil || course.From+course.To != "USDEUR" {
+			t.Fatalf("unexpected course %v, %v", course, err)
+		}
+	}
+
+	if loads != 1 {
+		t.Errorf("expected course loaded once, got %d loads", loads)
+	}
+
+	if course, ok := courses.Get("USDEUR"); !ok || course.To != "EUR" {
+		t.Errorf("expected cached course, got %v", course)
+	}
+
+	errLoad := errors.New("load failed")
+	_, err := courses.GetOrLoad(context.Background(), "USDRUB", func(context.Context) (*Course, error) {
+		return nil, errLoad
+	})
+
+	if err != errLoad {
+		t.Errorf("expected error %v, got %v", errLoad, err)
+	}
+}
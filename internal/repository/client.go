@@ -4,48 +4,61 @@ import (
 	"context"
 	"database/sql"
 	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/internal/cache"
 	"github.com/sidmal/ianua/pkg"
 	"go.uber.org/zap"
-	"time"
+	"strconv"
 )
 
 type Client struct {
 	Model
-	Name       string   `db:"name" json:"name" validate:"required"`
-	SecretKey  string   `db:"secret_key" json:"secret_key" validate:"required,max=255"`
-	FeePercent float64  `db:"fee_percent" json:"fee_percent" validate:"omitempty,numeric,gte=0,lte=100"`
-	Balance    float64  `db:"balance" json:"balance" validate:"omitempty,numeric,gte=0"`
-	Currency   string   `db:"currency" json:"currency" validate:"required,alpha,len=3"`
-	Projects   []string `db:"-" json:"-"`
+	Name       string      `db:"name" json:"name" validate:"required"`
+	SecretKey  string      `db:"secret_key" json:"secret_key" validate:"required,max=255"`
+	FeePercent pkg.Percent `db:"fee_percent" json:"fee_percent" validate:"omitempty,gte=0,lte=100000000"`
+	Balance    pkg.Amount  `db:"balance" json:"balance" validate:"omitempty,numeric,gte=0"`
+	Currency   string      `db:"currency" json:"currency" validate:"required,alpha,len=3"`
+	// The markup percent by which rate of conversion from client currency to provider currency is reduced
+	RateMarkup pkg.Percent `db:"rate_markup" json:"rate_markup" validate:"omitempty,gte=0,lt=100000000"`
+	// The url to notify client about transactions statuses changes
+	CallbackUrl *string  `db:"callback_url" json:"callback_url" validate:"omitempty,url"`
+	Projects    []string `db:"-" json:"-"`
 }
 
-type clientRepository repository
+type clientRepository struct {
+	*repository
+	cache *ClientCache
+}
 
-func newMerchantRepository(
-	db *sqlx.DB,
-	cacheLifetime int,
-	logger *zap.Logger,
-) MerchantRepositoryInterface {
+func newMerchantRepository(db *sqlx.DB, cache *ClientCache, logger *zap.Logger) MerchantRepositoryInterface {
 	repository := &clientRepository{
-		db:            db,
-		logger:        logger,
-		cacheLifetime: cacheLifetime,
-		cache:         make(Cached),
+		repository: &repository{
+			db:     db,
+			logger: logger,
+		},
+		cache: cache,
 	}
 	return repository
 }
 
 func (m *clientRepository) GetClient(ctx context.Context, uuid string) (*Client, error) {
-	cache, ok := m.cache[uuid]
-	current := time.Now()
+	return m.getClient(ctx, uuid, "uuid", uuid)
+}
 
-	if ok && cache.expire.After(current) {
-		return cache.value.(*Client), nil
-	}
+func (m *clientRepository) GetClientById(ctx context.Context, id uint64) (*Client, error) {
+	return m.getClient(ctx, "id:"+strconv.FormatUint(id, 10), "id", id)
+}
 
+func (m *clientRepository) getClient(ctx context.Context, cacheKey, field string, value interface{}) (*Client, error) {
+	return m.cache.GetOrLoad(ctx, cacheKey, func(ctx context.Context) (*Client, error) {
+		return m.loadClient(ctx, field, value)
+	})
+}
+
+func (m *clientRepository) loadClient(ctx context.Context, field string, value interface{}) (*Client, error) {
 	merchant := new(Client)
-	query := `SELECT uuid, name, secret_key, fee_percent, balance, currency FROM merchants WHERE uuid = $1 AND deleted_at IS NULL`
-	args := []interface{}{uuid}
+	query := `SELECT id, uuid, name, secret_key, fee_percent, balance, currency, rate_markup, callback_url FROM merchants 
+		WHERE ` + field + ` = $1 AND deleted_at IS NULL`
+	args := []interface{}{value}
 	err := m.db.GetContext(ctx, merchant, query, args...)
 
 	if err != nil {
@@ -62,14 +75,21 @@ func (m *clientRepository) GetClient(ctx context.Context, uuid string) (*Client,
 		return nil, err
 	}
 
-	if m.cacheLifetime > 0 {
-		m.mx.Lock()
-		m.cache[uuid] = &CachedValue{
-			value:  merchant,
-			expire: current.Add(time.Duration(m.cacheLifetime) * time.Second),
-		}
-		m.mx.Unlock()
-	}
-
 	return merchant, nil
 }
+
+func (m *clientRepository) GetAllCached() []*cache.Entry {
+	return m.cache.Entries()
+}
+
+func (m *clientRepository) RemoveCachedByKey(key string) {
+	m.cache.Remove(key)
+}
+
+func (m *clientRepository) RemoveAllCached() {
+	m.cache.Clear()
+}
+
+func (m *clientRepository) GetCacheStats() cache.Stats {
+	return m.cache.Stats()
+}
@@ -3,42 +3,105 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/internal/cache"
 	"github.com/sidmal/ianua/pkg"
 	"go.uber.org/zap"
 	"time"
 )
 
-type courseRepository repository
+const (
+	courseColumns = `id, "from", "to", value, date, created_at`
+)
 
-func newCourseRepository(db *sqlx.DB, cacheLifetime int, logger *zap.Logger) CourseRepositoryInterface {
-	repository := &courseRepository{
-		db:            db,
-		logger:        logger,
-		cacheLifetime: cacheLifetime,
-		cache:         make(Cached),
+// Course is a rate of conversion from currency to currency which is effective since date
+type Course struct {
+	Id    uint64   `db:"id" json:"id"`
+	From  string   `db:"from" json:"from" validate:"required,alpha,len=3"`
+	To    string   `db:"to" json:"to" validate:"required,alpha,len=3,nefield=From"`
+	Value pkg.Rate `db:"value" json:"value" validate:"required,gt=0"`
+	// The date since which rate is effective
+	Date      time.Time `db:"date" json:"date" validate:"required"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// CourseSnapshot is a copy of course which was used for conversion of transaction amounts,
+// it allows to reproduce conversion of transaction after course changing
+type CourseSnapshot struct {
+	// The transaction conversion, i.e. income_to_outcome
+	Conversion string `json:"conversion"`
+	// The flag that inverted course value was used for conversion
+	Inverse bool `json:"inverse"`
+	*Course
+}
+
+type CourseSnapshots []*CourseSnapshot
+
+func (m CourseSnapshots) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
 	}
-	return repository
+
+	return json.Marshal(m)
 }
 
-func (m *courseRepository) GetCourseRate(ctx context.Context, from, to string) (float32, error) {
-	cacheKey := from + to
-	cache, ok := m.cache[cacheKey]
-	current := time.Now()
+func (m *CourseSnapshots) Scan(src interface{}) error {
+	if src == nil {
+		*m = nil
+		return nil
+	}
 
-	if ok && cache.expire.After(current) {
-		return cache.value.(float32), nil
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type of course snapshots value")
 	}
 
-	rate := float32(0)
-	query := "SELECT `value` FROM courses WHERE `from` = $1 AND `to` = $2 AND `date` <= $3 AND `deleted_at` IS NULL " +
-		"ORDER BY date"
-	args := []interface{}{from, to, current}
-	err := m.db.GetContext(ctx, &rate, query, args...)
+	return json.Unmarshal(data, m)
+}
+
+type courseRepository struct {
+	*repository
+	cache *CourseCache
+}
+
+func newCourseRepository(db *sqlx.DB, cache *CourseCache, logger *zap.Logger) CourseRepositoryInterface {
+	repository := &courseRepository{
+		repository: &repository{
+			db:     db,
+			logger: logger,
+		},
+		cache: cache,
+	}
+	return repository
+}
+
+// GetCurrentCourse returns course which is effective now, it's cached by currencies pair
+func (m *courseRepository) GetCurrentCourse(ctx context.Context, from, to string) (*Course, error) {
+	return m.cache.GetOrLoad(ctx, from+to, func(ctx context.Context) (*Course, error) {
+		return m.GetCourse(ctx, from, to, time.Now())
+	})
+}
+
+// GetCourse returns course which was effective at received time, i.e. course with the latest date before it
+func (m *courseRepository) GetCourse(ctx context.Context, from, to string, at time.Time) (*Course, error) {
+	course := new(Course)
+	query := "SELECT " + courseColumns + ` FROM courses WHERE "from" = $1 AND "to" = $2 AND date <= $3 ` +
+		"AND deleted_at IS NULL ORDER BY date DESC, id DESC LIMIT 1"
+	args := []interface{}{from, to, at}
+	err := m.db.GetContext(ctx, course, query, args...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, pkg.ErrorCourseNotFound
+			return nil, pkg.ErrorCourseNotFound
 		}
 
 		m.logger.Error(
@@ -47,33 +110,70 @@ func (m *courseRepository) GetCourseRate(ctx context.Context, from, to string) (
 			zap.String(pkg.ErrorDatabaseFieldFilter, query),
 			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
 		)
-		return 0, pkg.ErrorUnknown
+		return nil, pkg.ErrorUnknown
 	}
 
-	if m.cacheLifetime > 0 {
-		m.mx.Lock()
-		m.cache[cacheKey] = &CachedValue{
-			value:  rate,
-			expire: current.Add(time.Duration(m.cacheLifetime) * time.Second),
+	return course, nil
+}
+
+// CreateCourses saves courses in one database transaction, value of existing course of currencies pair at the same
+// date is replaced. Cached courses of their currencies pairs are removed in current instance and other instances
+// are notified about changed pairs when database transaction is committed.
+func (m *courseRepository) CreateCourses(ctx context.Context, courses []*Course) error {
+	query := `INSERT INTO courses ("from", "to", value, date) VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("from", "to", date) WHERE deleted_at IS NULL DO UPDATE SET value = EXCLUDED.value
+		RETURNING id, created_at`
+	// payload is the same as payload of courses table trigger, so postgres folds duplicated notifications
+	notifyQuery := `SELECT pg_notify($1, json_build_object('table', 'courses', 'from', $2::TEXT, 'to', $3::TEXT)::TEXT)`
+	err := execInTx(ctx, m.db, nil, func(tx *sqlx.Tx) error {
+		pairs := make(map[string]*Course)
+
+		for _, course := range courses {
+			err := tx.QueryRowxContext(ctx, query, course.From, course.To, course.Value, course.Date).
+				Scan(&course.Id, &course.CreatedAt)
+
+			if err != nil {
+				return err
+			}
+
+			pairs[course.From+course.To] = course
+		}
+
+		for _, course := range pairs {
+			_, err := tx.ExecContext(ctx, notifyQuery, InvalidationChannel, course.From, course.To)
+
+			if err != nil {
+				return err
+			}
 		}
-		m.mx.Unlock()
+
+		return nil
+	})
+
+	if err != nil {
+		m.logger.Error(pkg.ErrorDatabaseQueryFailed, zap.Error(err), zap.String(pkg.ErrorDatabaseFieldFilter, query))
+		return pkg.ErrorUnknown
 	}
 
-	return rate, nil
+	for _, course := range courses {
+		m.RemoveCachedByKey(course.From + course.To)
+	}
+
+	return nil
 }
 
-func (m *courseRepository) GetAllCached() Cached {
-	return m.cache
+func (m *courseRepository) GetAllCached() []*cache.Entry {
+	return m.cache.Entries()
 }
 
-func (m *courseRepository) RemoveCachedByKey(key interface{}) {
-	m.mx.Lock()
-	delete(m.cache, key)
-	m.mx.Unlock()
+func (m *courseRepository) RemoveCachedByKey(key string) {
+	m.cache.Remove(key)
 }
 
 func (m *courseRepository) RemoveAllCached() {
-	m.mx.Lock()
-	m.cache = make(Cached)
-	m.mx.Unlock()
+	m.cache.Clear()
+}
+
+func (m *courseRepository) GetCacheStats() cache.Stats {
+	return m.cache.Stats()
 }
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/sidmal/ianua/pkg"
+	"testing"
+	"time"
+)
+
+func TestCourseSnapshots_Scan(t *testing.T) {
+	snapshots := CourseSnapshots{
+		{
+			Conversion: "income_to_outcome",
+			Inverse:    true,
+			Course: &Course{
+				Id:    1,
+				From:  "EUR",
+				To:    "USD",
+				Value: pkg.Rate(1100000),
+				Date:  time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	value, err := snapshots.Value()
+
+	if err != nil {
+		t.Fatalf("snapshots encoding failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		src  interface{}
+		err  bool
+	}{
+		{"bytes", value, false},
+		{"string", string(value.([]byte)), false},
+		{"unsupported type", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scanned CourseSnapshots
+			err := scanned.Scan(tt.src)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if len(scanned) != 1 {
+				t.Fatalf("expected 1 snapshot, got %d", len(scanned))
+			}
+
+			s := scanned[0]
+
+			if s.Conversion != "income_to_outcome" || !s.Inverse || s.Id != 1 || s.Value != pkg.Rate(1100000) ||
+				!s.Date.Equal(snapshots[0].Date) {
+				t.Errorf("unexpected snapshot %+v %+v", s, s.Course)
+			}
+		})
+	}
+}
+
+func TestCourseSnapshots_Null(t *testing.T) {
+	var snapshots CourseSnapshots
+
+	if value, err := snapshots.Value(); value != nil || err != nil {
+		t.Errorf("expected null value, got %v, %v", value, err)
+	}
+
+	scanned := CourseSnapshots{{}}
+
+	if err := scanned.Scan(nil); err != nil || scanned != nil {
+		t.Errorf("expected nil snapshots, got %v, %v", scanned, err)
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+)
+
+// LoadCurrencyExponents loads exponents of currencies from database table currencies, which is shared with
+// database function currency_exponent, so amounts are converted to minor units the same way in service and database
+func LoadCurrencyExponents(ctx context.Context, db *sqlx.DB) error {
+	var rows []struct {
+		Code     string `db:"code"`
+		Exponent int    `db:"exponent"`
+	}
+
+	query := `SELECT code, exponent FROM currencies`
+	err := db.SelectContext(ctx, &rows, query)
+
+	if err != nil {
+		return err
+	}
+
+	exponents := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		exponents[row.Code] = row.Exponent
+	}
+
+	pkg.SetCurrencyExponents(exponents)
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const (
+	// The pgx driver is used by all repositories through database/sql interface
+	driverName = "pgx"
+)
+
+// DatabaseOptions is a configuration of database connections pool
+type DatabaseOptions struct {
+	Url string
+	// The maximal count of open connections, zero value means no limit
+	MaxOpenConns int
+	// The maximal count of idle connections
+	MaxIdleConns int
+	// The maximal lifetime of connection, zero value means that connections are reused forever
+	ConnMaxLifetime time.Duration
+	// The maximal time during which connection may be idle, zero value means that idle connections aren't closed
+	ConnMaxIdleTime time.Duration
+	// The maximal time of connection establishing at start
+	ConnectTimeout time.Duration
+}
+
+// Connect opens database connections pool by options and checks that database is available
+func Connect(ctx context.Context, opts *DatabaseOptions) (*sqlx.DB, error) {
+	if opts.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.ConnectTimeout)
+		defer cancel()
+	}
+
+	db, err := sqlx.ConnectContext(ctx, driverName, opts.Url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+
+	return db, nil
+}
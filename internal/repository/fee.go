@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+)
+
+const (
+	FeePayerClient   = "client"
+	FeePayerCustomer = "customer"
+)
+
+// Fee is a tariff of fee for payments. Tariff can be set for client, for service or for pair of client and service,
+// the most specific tariff is applied. Client fee amounts are set in client's currency and customer fee amounts
+// are set in provider's currency.
+type Fee struct {
+	Id uint64 `db:"id"`
+	// The client identifier, tariff is applied to payments of all clients if it's empty.
+	ClientId *uint64 `db:"client_id"`
+	// The service identifier, tariff is applied to payments into all services if it's empty.
+	ServiceId *uint64 `db:"service_id"`
+	// The fee payer, client or customer.
+	Payer string `db:"payer"`
+	// The percent of payment amount.
+	Percent pkg.Percent `db:"percent"`
+	// The fixed fee amount which is added to percent of payment amount.
+	Fixed pkg.Amount `db:"fixed"`
+	// The minimal fee amount.
+	Min pkg.Amount `db:"min_amount"`
+	// The maximal fee amount, zero value means that fee isn't limited.
+	Max pkg.Amount `db:"max_amount"`
+	// The tiered schedule, percent and fixed fee of tier with the greatest lower bound which is not greater
+	// than payment amount are used instead of tariff's percent and fixed fee.
+	Tiers FeeTiers `db:"tiers"`
+	// The rounding mode of percent of payment amount, one of pkg.RoundHalfUp, pkg.RoundUp, pkg.RoundDown.
+	Rounding string `db:"rounding"`
+}
+
+type FeeTier struct {
+	// The lower bound of payment amount for which tier is applied.
+	From    pkg.Amount  `json:"from"`
+	Percent pkg.Percent `json:"percent"`
+	Fixed   pkg.Amount  `json:"fixed"`
+}
+
+type FeeTiers []*FeeTier
+
+func (m FeeTiers) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(m)
+}
+
+func (m *FeeTiers) Scan(src interface{}) error {
+	if src == nil {
+		*m = nil
+		return nil
+	}
+
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type of fee tiers value")
+	}
+
+	return json.Unmarshal(data, m)
+}
+
+// Calculate returns fee for payment amount in currency of payment amount, error is returned if fee doesn't fit
+// into amount range
+func (m *Fee) Calculate(amount *pkg.Money) (*pkg.Money, error) {
+	percent, fixed := m.Percent, m.Fixed
+	var tier *FeeTier
+
+	for _, t := range m.Tiers {
+		if t.From <= amount.Amount && (tier == nil || t.From > tier.From) {
+			tier = t
+		}
+	}
+
+	if tier != nil {
+		percent, fixed = tier.Percent, tier.Fixed
+	}
+
+	value, err := percent.Of(amount.Amount, m.Rounding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := pkg.NewMoney(value, amount.Currency).Add(pkg.NewMoney(fixed, amount.Currency))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fee.Amount < m.Min {
+		fee.Amount = m.Min
+	}
+
+	if m.Max > 0 && fee.Amount > m.Max {
+		fee.Amount = m.Max
+	}
+
+	return fee, nil
+}
+
+// specificity returns priority of tariff, tariff for pair of client and service has the highest priority
+func (m *Fee) specificity() int {
+	specificity := 0
+
+	if m.ClientId != nil {
+		specificity += 2
+	}
+
+	if m.ServiceId != nil {
+		specificity++
+	}
+
+	return specificity
+}
+
+type feeRepository repository
+
+func newFeeRepository(db *sqlx.DB, logger *zap.Logger) FeeRepositoryInterface {
+	repository := &feeRepository{
+		db:     db,
+		logger: logger,
+	}
+	return repository
+}
+
+// GetFee returns the most specific fee tariff of payer for payments of client into service,
+// nil is returned if tariff not found
+func (m *feeRepository) GetFee(ctx context.Context, payer string, clientId, serviceId uint64) (*Fee, error) {
+	query := "SELECT id, client_id, service_id, payer, percent, fixed, min_amount, max_amount, tiers, rounding " +
+		"FROM fees WHERE payer = $1 AND deleted_at IS NULL AND (client_id = $2 OR client_id IS NULL) " +
+		"AND (service_id = $3 OR service_id IS NULL)"
+	args := []interface{}{payer, clientId, serviceId}
+	fees := make([]*Fee, 0)
+
+	if err := m.db.SelectContext(ctx, &fees, query, args...); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	var fee *Fee
+
+	for _, f := range fees {
+		if fee == nil || f.specificity() > fee.specificity() {
+			fee = f
+		}
+	}
+
+	return fee, nil
+}
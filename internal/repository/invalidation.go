@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	// The channel of notifications about changes of cached tables, notifications are sent by database triggers
+	InvalidationChannel = "cache_invalidation"
+
+	// Names of repositories caches which can be flushed by cache invalidation notification
+	CacheClient  = "client"
+	CacheCourse  = "course"
+	CacheProject = "project"
+
+	invalidationMinBackoff = time.Second
+	invalidationMaxBackoff = 30 * time.Second
+)
+
+// invalidationPayload is a notification payload about changed row of cached table or about flush of repository cache
+type invalidationPayload struct {
+	Table string `json:"table"`
+	Id    uint64 `json:"id"`
+	Uuid  string `json:"uuid"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	// The repository cache name and the key of removed value, all values are removed if key is empty
+	Cache string `json:"cache,omitempty"`
+	Key   string `json:"key,omitempty"`
+}
+
+// InvalidationListener listens notifications about changes of cached tables and removes changed values from
+// repositories caches of current instance. If listening connection is lost then cached values expire by lifetime
+// until connection is restored, all caches are flushed after reconnection because notifications could be missed.
+type InvalidationListener struct {
+	repository  Interface
+	databaseUrl string
+	logger      *zap.Logger
+}
+
+func NewInvalidationListener(repository Interface, databaseUrl string, logger *zap.Logger) *InvalidationListener {
+	listener := &InvalidationListener{
+		repository:  repository,
+		databaseUrl: databaseUrl,
+		logger:      logger,
+	}
+	return listener
+}
+
+// Run listens notifications until context is done, connection is restored with exponential backoff
+func (m *InvalidationListener) Run(ctx context.Context) {
+	backoff := invalidationMinBackoff
+	connected := false
+
+	for {
+		err := m.listen(ctx, func() {
+			if connected {
+				m.removeAllCached()
+			}
+
+			connected = true
+			backoff = invalidationMinBackoff
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.logger.Error("cache invalidation listening failed", zap.Error(err), zap.Duration("retry_after", backoff))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > invalidationMaxBackoff {
+			backoff = invalidationMaxBackoff
+		}
+	}
+}
+
+func (m *InvalidationListener) listen(ctx context.Context, onConnect func()) error {
+	conn, err := pgx.Connect(ctx, m.databaseUrl)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+InvalidationChannel); err != nil {
+		return err
+	}
+
+	onConnect()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		m.invalidate(notification.Payload)
+	}
+}
+
+func (m *InvalidationListener) invalidate(data string) {
+	payload := new(invalidationPayload)
+
+	if err := json.Unmarshal([]byte(data), payload); err != nil {
+		m.logger.Error("cache invalidation payload parsing failed", zap.Error(err), zap.String("payload", data))
+		return
+	}
+
+	if payload.Cache != "" {
+		m.flush(payload.Cache, payload.Key)
+		return
+	}
+
+	switch payload.Table {
+	case "merchants":
+		clients := m.repository.GetClientRepository()
+		clients.RemoveCachedByKey(payload.Uuid)
+		clients.RemoveCachedByKey("id:" + strconv.FormatUint(payload.Id, 10))
+	case "services":
+		m.repository.GetProjectRepository().RemoveCachedByKey(payload.Uuid)
+	case "providers":
+		// provider is cached together with each its service, so all services are removed too
+		m.repository.GetProjectRepository().RemoveAllCached()
+	case "courses":
+		m.repository.GetCourseRepository().RemoveCachedByKey(payload.From + payload.To)
+	default:
+		m.logger.Warn("cache invalidation for unknown table received", zap.String("table", payload.Table))
+	}
+}
+
+// flush removes value by key or all values if key is empty from repository cache by cache name
+func (m *InvalidationListener) flush(name, key string) {
+	repositoryCache, ok := GetCaches(m.repository)[name]
+
+	if !ok {
+		m.logger.Warn("cache invalidation for unknown cache received", zap.String("cache", name))
+		return
+	}
+
+	if key == "" {
+		repositoryCache.RemoveAllCached()
+	} else {
+		repositoryCache.RemoveCachedByKey(key)
+	}
+}
+
+func (m *InvalidationListener) removeAllCached() {
+	m.repository.GetClientRepository().RemoveAllCached()
+	m.repository.GetProjectRepository().RemoveAllCached()
+	m.repository.GetCourseRepository().RemoveAllCached()
+}
+
+// GetCaches returns repositories caches by names
+func GetCaches(repository Interface) map[string]Cache {
+	caches := map[string]Cache{
+		CacheClient:  repository.GetClientRepository(),
+		CacheCourse:  repository.GetCourseRepository(),
+		CacheProject: repository.GetProjectRepository(),
+	}
+	return caches
+}
+
+// NotifyCacheInvalidation notifies all instances that value by key or all values if key is empty must be removed
+// from repository cache by cache name
+func (m *Repository) NotifyCacheInvalidation(ctx context.Context, name, key string) error {
+	payload, err := json.Marshal(&invalidationPayload{Cache: name, Key: key})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = m.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", InvalidationChannel, string(payload))
+	return err
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"github.com/sidmal/ianua/internal/cache"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvalidationListener_invalidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{
+			name:     "client removed by uuid and identifier",
+			payload:  `{"table":"merchants","id":1,"uuid":"c-1"}`,
+			expected: "USDEUR,c-2,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "service removed by uuid",
+			payload:  `{"table":"services","id":1,"uuid":"s-1"}`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2,provider:p-1,s-2",
+		},
+		{
+			name:     "services and providers removed by provider change",
+			payload:  `{"table":"providers","id":1,"uuid":"p-1"}`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2",
+		},
+		{
+			name:     "course removed by currencies pair",
+			payload:  `{"table":"courses","id":1,"from":"USD","to":"EUR"}`,
+			expected: "c-1,c-2,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "client flushed by key",
+			payload:  `{"cache":"client","key":"c-2"}`,
+			expected: "USDEUR,c-1,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "provider flushed by prefixed key",
+			payload:  `{"cache":"project","key":"provider:p-1"}`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2,s-1,s-2",
+		},
+		{
+			name:     "course cache flushed",
+			payload:  `{"cache":"course"}`,
+			expected: "c-1,c-2,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "unknown cache",
+			payload:  `{"cache":"transaction"}`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "unknown table",
+			payload:  `{"table":"transactions","id":1}`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+		{
+			name:     "invalid payload",
+			payload:  `merchants`,
+			expected: "USDEUR,c-1,c-2,id:1,id:2,provider:p-1,s-1,s-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newCachedRepository()
+			NewInvalidationListener(repo, "", zap.NewNop()).invalidate(tt.payload)
+
+			if keys := cachedKeys(repo); keys != tt.expected {
+				t.Errorf("expected cached keys %s, got %s", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestInvalidationListener_removeAllCached(t *testing.T) {
+	repo := newCachedRepository()
+	NewInvalidationListener(repo, "", zap.NewNop()).removeAllCached()
+
+	if keys := cachedKeys(repo); keys != "" {
+		t.Errorf("expected empty caches, got %s", keys)
+	}
+}
+
+// newCachedRepository returns repository without database with cached clients, services, providers and courses
+func newCachedRepository() *Repository {
+	newCache := func() *cache.Cache {
+		return cache.New(&cache.Options{Lifetime: time.Minute})
+	}
+
+	clients := &ClientCache{Cache: newCache()}
+	services := &ServiceCache{Cache: newCache()}
+	providers := &ProviderCache{Cache: newCache()}
+	courses := &CourseCache{Cache: newCache()}
+
+	clients.Set("c-1", &Client{})
+	clients.Set("id:1", &Client{})
+	clients.Set("c-2", &Client{})
+	clients.Set("id:2", &Client{})
+	services.Set("s-1", &Service{})
+	services.Set("s-2", &Service{})
+	providers.Set("p-1", &Provider{})
+	courses.Set("USDEUR", &Course{})
+
+	repository := &Repository{
+		client:  newMerchantRepository(nil, clients, zap.NewNop()),
+		project: newProviderRepository(nil, services, providers, zap.NewNop()),
+		course:  newCourseRepository(nil, courses, zap.NewNop()),
+	}
+	return repository
+}
+
+// cachedKeys returns sorted keys of all repositories caches joined by comma
+func cachedKeys(repository Interface) string {
+	keys := make([]string, 0)
+
+	for _, c := range []Cache{
+		repository.GetClientRepository(),
+		repository.GetProjectRepository(),
+		repository.GetCourseRepository(),
+	} {
+		for _, entry := range c.GetAllCached() {
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
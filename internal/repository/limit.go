@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+)
+
+// ClientLimit overrides service amount limits for payments of client into service, nil limit means that
+// service limit is used and zero limit means that payments aren't limited
+type ClientLimit struct {
+	Id        uint64 `db:"id"`
+	ClientId  uint64 `db:"client_id"`
+	ServiceId uint64 `db:"service_id"`
+	// The minimal amount in service provider currency of one payment
+	MinAmount *pkg.Amount `db:"min_amount"`
+	// The maximal amount in service provider currency of one payment
+	MaxAmount *pkg.Amount `db:"max_amount"`
+	// The maximal amount in service provider currency of payments into one account per day
+	DailyLimit *pkg.Amount `db:"daily_limit"`
+	// The maximal amount in service provider currency of payments into one account per month
+	MonthlyLimit *pkg.Amount `db:"monthly_limit"`
+}
+
+type limitRepository repository
+
+func newLimitRepository(db *sqlx.DB, logger *zap.Logger) LimitRepositoryInterface {
+	repository := &limitRepository{
+		db:     db,
+		logger: logger,
+	}
+	return repository
+}
+
+// GetClientLimit returns limits of client for service, nil is returned if client hasn't own limits
+func (m *limitRepository) GetClientLimit(ctx context.Context, clientId, serviceId uint64) (*ClientLimit, error) {
+	limit := new(ClientLimit)
+	query := "SELECT id, client_id, service_id, min_amount, max_amount, daily_limit, monthly_limit FROM client_limits " +
+		"WHERE client_id = $1 AND service_id = $2 AND deleted_at IS NULL"
+	args := []interface{}{clientId, serviceId}
+	err := m.db.GetContext(ctx, limit, query, args...)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return limit, nil
+}
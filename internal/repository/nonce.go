@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"time"
+)
+
+type nonceRepository repository
+
+func newNonceRepository(db *sqlx.DB, logger *zap.Logger) NonceRepositoryInterface {
+	repository := &nonceRepository{
+		db:     db,
+		logger: logger,
+	}
+	return repository
+}
+
+// Add remembers request nonce of client until expiration time, it returns false if nonce is already used by client
+// and isn't expired yet. Nonces are shared by all service instances, so request can't be replayed to other instance.
+func (m *nonceRepository) Add(ctx context.Context, clientId uint64, nonce string, expire time.Time) (bool, error) {
+	query := "INSERT INTO request_nonces (client_id, nonce, expire_at) VALUES ($1, $2, $3) " +
+		"ON CONFLICT (client_id, nonce) DO UPDATE SET expire_at = EXCLUDED.expire_at " +
+		"WHERE request_nonces.expire_at < $4 RETURNING client_id"
+	args := []interface{}{clientId, nonce, expire, time.Now()}
+	id := uint64(0)
+	err := m.db.GetContext(ctx, &id, query, args...)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return false, pkg.ErrorUnknown
+	}
+
+	return true, nil
+}
+
+// RemoveExpired removes nonces which were expired before received time
+func (m *nonceRepository) RemoveExpired(ctx context.Context, before time.Time) error {
+	query := "DELETE FROM request_nonces WHERE expire_at < $1"
+
+	if _, err := m.db.ExecContext(ctx, query, before); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, before),
+		)
+		return pkg.ErrorUnknown
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/pkg"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	NotificationStatusPending   = "pending"
+	NotificationStatusDelivered = "delivered"
+	NotificationStatusFailed    = "failed"
+)
+
+type Notification struct {
+	Model
+	// The client unique identifier in billing system.
+	ClientId uint64 `db:"client_id"`
+	// The transaction unique identifier in billing system.
+	TransactionId uint64 `db:"transaction_id"`
+	// The client url to send notification.
+	Url string `db:"url"`
+	// The notification body.
+	Payload []byte `db:"payload"`
+	// The notification delivery status.
+	Status string `db:"status"`
+	// The count of delivery attempts.
+	Attempts int `db:"attempts"`
+	// The time of next delivery attempt.
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+}
+
+// NotificationPayload is a body of notification about transaction status which is sent to client
+type NotificationPayload struct {
+	Id           string      `json:"id"`
+	ClientTxnId  *string     `json:"client_txn_id,omitempty"`
+	Account      string      `json:"account"`
+	Amount       pkg.Decimal `json:"amount"`
+	Currency     string      `json:"currency"`
+	Status       string      `json:"status"`
+	RejectReason string      `json:"reject_reason,omitempty"`
+	Metadata     Metadata    `json:"metadata,omitempty"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type NotificationDelivery struct {
+	Id uint64 `db:"id"`
+	// The notification unique identifier in billing system.
+	NotificationId uint64 `db:"notification_id"`
+	// The delivery attempt number.
+	Attempt int `db:"attempt"`
+	// The http status code of client response, zero if response wasn't received.
+	ResponseStatus int `db:"response_status"`
+	// The body of client response.
+	ResponseBody string `db:"response_body"`
+	// The error of delivery attempt.
+	Error string `db:"error"`
+	// The delivery attempt duration.
+	Duration  time.Duration `db:"duration"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+type notificationRepository repository
+
+const (
+	notificationColumns = "id, uuid, client_id, transaction_id, url, payload, status, attempts, next_attempt_at, " +
+		"created_at, updated_at, deleted_at"
+)
+
+func newNotificationRepository(db *sqlx.DB, logger *zap.Logger) NotificationRepositoryInterface {
+	repository := &notificationRepository{
+		db:     db,
+		logger: logger,
+	}
+	return repository
+}
+
+// createNotification puts notification about transaction status to queue in database transaction which changes
+// transaction status, notification isn't created if client hasn't callback url
+func createNotification(ctx context.Context, tx *sqlx.Tx, txn *Transaction, updatedAt time.Time) error {
+	payload, err := json.Marshal(&NotificationPayload{
+		Id:           txn.Uuid,
+		ClientTxnId:  txn.ClientTxnId,
+		Account:      txn.Account,
+		Amount:       txn.IncomeAmount.Decimal(txn.IncomeCurrency),
+		Currency:     txn.IncomeCurrency,
+		Status:       txn.Status,
+		RejectReason: txn.GatewayRejectReason,
+		Metadata:     txn.Metadata,
+		UpdatedAt:    updatedAt,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO notifications (client_id, transaction_id, url, payload, status, attempts, next_attempt_at) " +
+		"SELECT id, $1, callback_url, $2, $3, 0, $4 FROM merchants WHERE id = $5 AND callback_url IS NOT NULL " +
+		"AND callback_url <> ''"
+	_, err = tx.ExecContext(ctx, query, txn.Id, payload, NotificationStatusPending, updatedAt, txn.ClientId)
+
+	return err
+}
+
+// Claim returns pending notifications which delivery time came and postpones their next attempt for lease time,
+// so other instances will not deliver same notifications concurrently.
+func (m *notificationRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error) {
+	current := time.Now()
+	query := "UPDATE notifications SET next_attempt_at = $1 WHERE id IN (SELECT id FROM notifications " +
+		"WHERE status = $2 AND next_attempt_at <= $3 AND deleted_at IS NULL ORDER BY next_attempt_at LIMIT $4 " +
+		"FOR UPDATE SKIP LOCKED) RETURNING " + notificationColumns
+	args := []interface{}{current.Add(lease), NotificationStatusPending, current, limit}
+	notifications := make([]*Notification, 0, limit)
+	err := m.db.SelectContext(ctx, &notifications, query, args...)
+
+	if err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return notifications, nil
+}
+
+// SaveDelivery writes delivery attempt to delivery log and saves notification delivery state
+func (m *notificationRepository) SaveDelivery(
+	ctx context.Context,
+	notification *Notification,
+	delivery *NotificationDelivery,
+) error {
+	query := "INSERT INTO notification_deliveries (notification_id, attempt, response_status, response_body, error, " +
+		"duration) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+	args := []interface{}{
+		notification.Id, delivery.Attempt, delivery.ResponseStatus, delivery.ResponseBody, delivery.Error,
+		delivery.Duration,
+	}
+	err := runInTx(ctx, m.db, nil, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowxContext(ctx, query, args...).Scan(&delivery.Id, &delivery.CreatedAt); err != nil {
+			return err
+		}
+
+		stateQuery := "UPDATE notifications SET status = $1, attempts = $2, next_attempt_at = $3, updated_at = $4 " +
+			"WHERE id = $5"
+		_, err := tx.ExecContext(
+			ctx,
+			stateQuery,
+			notification.Status, notification.Attempts, notification.NextAttemptAt, time.Now(), notification.Id,
+		)
+
+		return err
+	})
+
+	if err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return pkg.ErrorUnknown
+	}
+
+	return nil
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgconn"
 	"github.com/jmoiron/sqlx"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/sidmal/ianua/internal/cache"
+	"github.com/sidmal/ianua/pkg"
 	"go.uber.org/zap"
-	"sync"
 	"time"
 )
 
@@ -17,40 +20,53 @@ const (
 	collectionTransaction = "transaction"
 )
 
+const (
+	// Maximal count of attempts to execute database transaction which failed by serialization failure
+	maxTxAttempts = 5
+
+	pgErrorSerializationFailure = "40001"
+	pgErrorDeadlockDetected     = "40P01"
+)
+
 type Interface interface {
 	GetClientRepository() MerchantRepositoryInterface
 	GetCourseRepository() CourseRepositoryInterface
 	GetProjectRepository() ProviderRepositoryInterface
 	GetTransactionRepository() TransactionRepositoryInterface
+	GetNotificationRepository() NotificationRepositoryInterface
 	GetAccountingEntryRepository() AccountingEntryRepositoryInterface
+	GetFeeRepository() FeeRepositoryInterface
+	GetLimitRepository() LimitRepositoryInterface
+	GetNonceRepository() NonceRepositoryInterface
+	NotifyCacheInvalidation(ctx context.Context, name, key string) error
+	Ping(ctx context.Context) error
+	GetDatabaseStats() sql.DBStats
 }
 
 type CacheLifetime struct {
 	Course  int
 	Client  int
 	Project int
+	// The maximal count of values in each repository cache, zero value means no limit
+	MaxSize int
 }
 
 type Repository struct {
-	course  CourseRepositoryInterface
-	client  MerchantRepositoryInterface
-	project ProviderRepositoryInterface
-	order   TransactionRepositoryInterface
-}
-
-type Cached map[string]*CachedValue
-
-type CachedValue struct {
-	value  interface{}
-	expire time.Time
+	db              *sqlx.DB
+	course          CourseRepositoryInterface
+	client          MerchantRepositoryInterface
+	project         ProviderRepositoryInterface
+	transaction     TransactionRepositoryInterface
+	notification    NotificationRepositoryInterface
+	accountingEntry AccountingEntryRepositoryInterface
+	fee             FeeRepositoryInterface
+	limit           LimitRepositoryInterface
+	nonce           NonceRepositoryInterface
 }
 
 type repository struct {
-	db            *sqlx.DB
-	logger        *zap.Logger
-	mx            sync.Mutex
-	cacheLifetime int
-	cache         Cached
+	db     *sqlx.DB
+	logger *zap.Logger
 }
 
 type Model struct {
@@ -62,34 +78,93 @@ type Model struct {
 }
 
 type Cache interface {
-	GetAllCached() Cached
-	RemoveCachedByKey(key interface{})
+	GetAllCached() []*cache.Entry
+	RemoveCachedByKey(key string)
 	RemoveAllCached()
+	GetCacheStats() cache.Stats
 }
 
 type CourseRepositoryInterface interface {
-	GetCourseRate(ctx context.Context, from, to string) (float32, error)
+	Cache
+	GetCurrentCourse(ctx context.Context, from, to string) (*Course, error)
+	GetCourse(ctx context.Context, from, to string, at time.Time) (*Course, error)
+	CreateCourses(ctx context.Context, courses []*Course) error
 }
 
 type MerchantRepositoryInterface interface {
+	Cache
 	GetClient(ctx context.Context, uuid string) (*Client, error)
+	GetClientById(ctx context.Context, id uint64) (*Client, error)
 }
 
 type ProviderRepositoryInterface interface {
+	Cache
 	GetService(ctx context.Context, uuid string) (*Service, error)
-	GetProvider(ctx context.Context, oid primitive.ObjectID) (*Provider, error)
+	GetProvider(ctx context.Context, uuid string) (*Provider, error)
 }
 
 type TransactionRepositoryInterface interface {
+	GetTransactionById(ctx context.Context, id uint64) (*Transaction, error)
 	GetTransactionByClientTxnId(ctx context.Context, clientId uint64, clientTxnId string) (*Transaction, error)
-	//CreateOrder(in *PaymentIn) (*Order, error)
-	//Update(id int64, status int, gatewayOrderId string) error
-	//RejectPayment(id int64) error
+	GetTransactionByProviderTxnId(ctx context.Context, handlerId, providerTxnId string) (*Transaction, error)
+	Create(ctx context.Context, in *Transaction, limits []*VelocityLimit) (*Transaction, bool, error)
+	SetInProgress(ctx context.Context, txn *Transaction, providerTxnId string) error
+	Complete(ctx context.Context, txn *Transaction, providerTxnId string) error
+	Reject(ctx context.Context, txn *Transaction, providerTxnId, reason string) error
+	Transit(ctx context.Context, txn *Transaction, status, reason string) error
+	GetStatusHistory(ctx context.Context, txnId uint64) ([]*TransactionStatusChange, error)
+	GetStaleTransactions(ctx context.Context, updatedBefore time.Time, limit int) ([]*StaleTransaction, error)
+}
+
+type NotificationRepositoryInterface interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error)
+	SaveDelivery(ctx context.Context, notification *Notification, delivery *NotificationDelivery) error
+}
+
+type AccountingEntryRepositoryInterface interface {
+	GetEntriesByTransactionId(ctx context.Context, txnId uint64) ([]*AccountingEntry, error)
+	GetAccountBalance(ctx context.Context, account string, ownerId uint64, currency string, at time.Time) (pkg.Amount, error)
+	GetUnbalancedTransactions(ctx context.Context) ([]uint64, error)
+}
+
+type FeeRepositoryInterface interface {
+	GetFee(ctx context.Context, payer string, clientId, serviceId uint64) (*Fee, error)
+}
+
+type LimitRepositoryInterface interface {
+	GetClientLimit(ctx context.Context, clientId, serviceId uint64) (*ClientLimit, error)
+}
+
+type NonceRepositoryInterface interface {
+	Add(ctx context.Context, clientId uint64, nonce string, expire time.Time) (bool, error)
+	RemoveExpired(ctx context.Context, before time.Time) error
 }
 
 func NewRepository(db *sqlx.DB, cacheLifetime *CacheLifetime, logger *zap.Logger) Interface {
 	repository := &Repository{
-		course: newCourseRepository(db, cacheLifetime.Course, logger),
+		db: db,
+		course: newCourseRepository(
+			db,
+			&CourseCache{Cache: newCache(cacheLifetime.Course, cacheLifetime.MaxSize)},
+			logger,
+		),
+		client: newMerchantRepository(
+			db,
+			&ClientCache{Cache: newCache(cacheLifetime.Client, cacheLifetime.MaxSize)},
+			logger,
+		),
+		project: newProviderRepository(
+			db,
+			&ServiceCache{Cache: newCache(cacheLifetime.Project, cacheLifetime.MaxSize)},
+			&ProviderCache{Cache: newCache(cacheLifetime.Project, cacheLifetime.MaxSize)},
+			logger,
+		),
+		transaction:     newTransactionRepository(db, logger),
+		notification:    newNotificationRepository(db, logger),
+		accountingEntry: newAccountingEntryRepository(db, logger),
+		fee:             newFeeRepository(db, logger),
+		limit:           newLimitRepository(db, logger),
+		nonce:           newNonceRepository(db, logger),
 	}
 
 	return repository
@@ -107,10 +182,84 @@ func (m *Repository) GetProjectRepository() ProviderRepositoryInterface {
 	return m.project
 }
 
-func (m *Repository) GetOrderRepository() OrderRepositoryInterface {
-	return m.order
+func (m *Repository) GetTransactionRepository() TransactionRepositoryInterface {
+	return m.transaction
+}
+
+func (m *Repository) GetNotificationRepository() NotificationRepositoryInterface {
+	return m.notification
 }
 
 func (m *Repository) GetAccountingEntryRepository() AccountingEntryRepositoryInterface {
 	return m.accountingEntry
 }
+
+func (m *Repository) GetFeeRepository() FeeRepositoryInterface {
+	return m.fee
+}
+
+func (m *Repository) GetLimitRepository() LimitRepositoryInterface {
+	return m.limit
+}
+
+func (m *Repository) GetNonceRepository() NonceRepositoryInterface {
+	return m.nonce
+}
+
+// Ping checks that database is available
+func (m *Repository) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+func (m *Repository) GetDatabaseStats() sql.DBStats {
+	return m.db.Stats()
+}
+
+// runInTx executes function in database transaction and commits transaction if function returns no error.
+// Transaction is retried if it failed by serialization failure or deadlock.
+func runInTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	var err error
+
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		if err = execInTx(ctx, db, opts, fn); !isRetryableTxError(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// newCache returns cache with lifetime in seconds, expired values are evicted once per lifetime
+func newCache(lifetime, maxSize int) *cache.Cache {
+	opts := &cache.Options{
+		Lifetime:         time.Duration(lifetime) * time.Second,
+		MaxSize:          maxSize,
+		EvictionInterval: time.Duration(lifetime) * time.Second,
+	}
+	return cache.New(opts)
+}
+
+func execInTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, opts)
+
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgErrorSerializationFailure || pgErr.Code == pgErrorDeadlockDetected
+}
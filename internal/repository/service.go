@@ -4,10 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"github.com/jmoiron/sqlx"
+	"github.com/sidmal/ianua/internal/cache"
 	"github.com/sidmal/ianua/pkg"
 	"go.uber.org/zap"
 	"regexp"
-	"time"
+	"strings"
 )
 
 type Provider struct {
@@ -30,58 +31,69 @@ type Service struct {
 	AccountRegexp string `db:"account_regexp" json:"account_regexp"`
 	// The phrase to get account from customer if payment init from payment form
 	AccountPhrase string `db:"account_phrase" json:"account_phrase"`
+	// The checksum algorithm for check customer's account, one of luhn, mod11, iban or empty to skip checksum check
+	AccountChecksum string `db:"account_checksum" json:"account_checksum" validate:"omitempty,oneof=luhn mod11 iban"`
+	// The flag that customer's account must be checked by provider gateway before payment creation
+	AccountPreCheck bool `db:"account_pre_check" json:"account_pre_check"`
 	// The minimal amount in service provider currency to pay into service
-	MinAmount float64 `db:"min_amount" json:"min_amount"`
+	MinAmount pkg.Amount `db:"min_amount" json:"min_amount"`
 	// The maximal amount in service provider currency to pay into service
-	MaxAmount float64 `db:"max_amount" json:"max_amount"`
+	MaxAmount pkg.Amount `db:"max_amount" json:"max_amount"`
+	// The maximal amount in service provider currency of payments into one account per day, zero value means no limit
+	DailyLimit pkg.Amount `db:"daily_limit" json:"daily_limit"`
+	// The maximal amount in service provider currency of payments into one account per month, zero value means no limit
+	MonthlyLimit pkg.Amount `db:"monthly_limit" json:"monthly_limit"`
 	// The unique service identifier in provider's billing system
 	ExternalId string `db:"external_id" json:"external_id"`
 	// Fee cost by which the payment amount must be reduced, i.e. customer receiving amount which will be reduced by this fee.
 	// For example, if customer's want to pay amount 100 USD and this fee value is 3%, than after payment customer's
 	// receive amount which will calculate by next formula: 100 - 100 * 0.03 = 97 USD.
-	FeePercent float64 `db:"fee_percent" json:"fee_percent" validate:"omitempty,numeric,gte=0,lte=100"`
+	FeePercent pkg.Percent `db:"fee_percent" json:"fee_percent" validate:"omitempty,gte=0,lte=100000000"`
 	// The technical field to save compiled regexp in cache
 	CacheAccountRegexp *regexp.Regexp `db:"-" json:"-"`
 	// The technical field to get provider data from service data
 	Provider *Provider `db:"-" json:"-"`
 }
 
+const (
+	cacheKeyProviderPrefix = "provider:"
+)
+
 type projectRepository struct {
 	*repository
-	cacheLifetimeProvider int
-	cacheProvider         Cached
+	cache         *ServiceCache
+	cacheProvider *ProviderCache
 }
 
 func newProviderRepository(
 	db *sqlx.DB,
-	cacheLifetimeProject int,
-	cacheLifetimeProvider int,
+	cacheProject *ServiceCache,
+	cacheProvider *ProviderCache,
 	logger *zap.Logger,
 ) ProviderRepositoryInterface {
 	repository := &projectRepository{
 		repository: &repository{
-			db:            db,
-			logger:        logger,
-			cacheLifetime: cacheLifetimeProject,
-			cache:         make(Cached),
+			db:     db,
+			logger: logger,
 		},
-		cacheLifetimeProvider: cacheLifetimeProvider,
-		cacheProvider:         make(Cached),
+		cache:         cacheProject,
+		cacheProvider: cacheProvider,
 	}
 	return repository
 }
 
 func (m *projectRepository) GetService(ctx context.Context, uuid string) (*Service, error) {
-	cache, ok := m.cache[uuid]
-	current := time.Now()
-
-	if ok && cache.expire.After(current) {
-		return cache.value.(*Service), nil
-	}
+	return m.cache.GetOrLoad(ctx, uuid, func(ctx context.Context) (*Service, error) {
+		return m.loadService(ctx, uuid)
+	})
+}
 
+func (m *projectRepository) loadService(ctx context.Context, uuid string) (*Service, error) {
 	service := new(Service)
-	query := `SELECT id, uuid, provider_id, name, account_regexp, account_phrase, external_id, min_amount, max_amount, 
-		fee_percent, deleted_at FROM services WHERE uuid = $1`
+	query := `SELECT s.id, s.uuid, p.uuid AS provider_uuid, s.name, s.account_regexp, s.account_phrase, 
+		s.account_checksum, s.account_pre_check, s.external_id, s.min_amount, s.max_amount, s.daily_limit, 
+		s.monthly_limit, s.fee_percent, s.deleted_at 
+		FROM services s JOIN providers p ON p.id = s.provider_id WHERE s.uuid = $1`
 	args := []interface{}{uuid}
 	err := m.db.GetContext(ctx, service, query, args...)
 
@@ -99,9 +111,8 @@ func (m *projectRepository) GetService(ctx context.Context, uuid string) (*Servi
 		return nil, err
 	}
 
-	if service.DeletedAt.IsZero() {
+	if service.DeletedAt != nil {
 		return nil, pkg.ErrorServiceInactive
-
 	}
 
 	provider, err := m.GetProvider(ctx, service.ProviderUuid)
@@ -111,31 +122,25 @@ func (m *projectRepository) GetService(ctx context.Context, uuid string) (*Servi
 	}
 
 	if service.AccountRegexp != "" {
-		service.CacheAccountRegexp = regexp.MustCompile(service.AccountRegexp)
-	}
-
-	service.Provider = provider
+		service.CacheAccountRegexp, err = regexp.Compile(service.AccountRegexp)
 
-	if m.cacheLifetime > 0 {
-		m.mx.Lock()
-		m.cache[uuid] = &CachedValue{
-			value:  service,
-			expire: current.Add(time.Duration(m.cacheLifetime) * time.Second),
+		if err != nil {
+			m.logger.Error("service account regexp compilation failed", zap.Error(err), zap.String("uuid", uuid))
+			return nil, pkg.ErrorUnknown
 		}
-		m.mx.Unlock()
 	}
 
+	service.Provider = provider
 	return service, nil
 }
 
 func (m *projectRepository) GetProvider(ctx context.Context, uuid string) (*Provider, error) {
-	cache, ok := m.cacheProvider[uuid]
-	current := time.Now()
-
-	if ok && cache.expire.After(current) {
-		return cache.value.(*Provider), nil
-	}
+	return m.cacheProvider.GetOrLoad(ctx, uuid, func(ctx context.Context) (*Provider, error) {
+		return m.loadProvider(ctx, uuid)
+	})
+}
 
+func (m *projectRepository) loadProvider(ctx context.Context, uuid string) (*Provider, error) {
 	provider := new(Provider)
 	query := `SELECT id, uuid, name, currency, handler, deleted_at FROM providers WHERE uuid = $1`
 	args := []interface{}{uuid}
@@ -155,30 +160,43 @@ func (m *projectRepository) GetProvider(ctx context.Context, uuid string) (*Prov
 		return nil, err
 	}
 
-	if m.cacheLifetime > 0 {
-		m.mx.Lock()
-		m.cache[uuid] = &CachedValue{
-			value:  provider,
-			expire: current.Add(time.Duration(m.cacheLifetimeProvider) * time.Second),
-		}
-		m.mx.Unlock()
-	}
-
 	return provider, nil
 }
 
-func (m *projectRepository) GetAllCached() Cached {
-	return m.cache
+// GetAllCached returns cached services and providers, keys of providers are prefixed by "provider:"
+func (m *projectRepository) GetAllCached() []*cache.Entry {
+	entries := m.cache.Entries()
+
+	for _, entry := range m.cacheProvider.Entries() {
+		entry.Key = cacheKeyProviderPrefix + entry.Key
+		entries = append(entries, entry)
+	}
+
+	return entries
 }
 
-func (m *projectRepository) RemoveCachedByKey(key interface{}) {
-	m.mx.Lock()
-	delete(m.cache, key)
-	m.mx.Unlock()
+// RemoveCachedByKey removes cached service by its uuid or cached provider by its uuid prefixed by "provider:"
+func (m *projectRepository) RemoveCachedByKey(key string) {
+	if strings.HasPrefix(key, cacheKeyProviderPrefix) {
+		m.cacheProvider.Remove(strings.TrimPrefix(key, cacheKeyProviderPrefix))
+		return
+	}
+
+	m.cache.Remove(key)
 }
 
 func (m *projectRepository) RemoveAllCached() {
-	m.mx.Lock()
-	m.cache = make(Cached)
-	m.mx.Unlock()
+	m.cache.Clear()
+	m.cacheProvider.Clear()
+}
+
+func (m *projectRepository) GetCacheStats() cache.Stats {
+	services, providers := m.cache.Stats(), m.cacheProvider.Stats()
+	stats := cache.Stats{
+		Hits:      services.Hits + providers.Hits,
+		Misses:    services.Misses + providers.Misses,
+		Evictions: services.Evictions + providers.Evictions,
+		Size:      services.Size + providers.Size,
+	}
+	return stats
 }
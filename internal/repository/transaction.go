@@ -3,16 +3,56 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/sidmal/ianua/pkg"
 	"go.uber.org/zap"
+	"time"
 )
 
 const (
-	TransactionStatusNew        = "new"
-	TransactionStatusInProgress = "in_progress"
-	TransactionStatusCompleted  = "completed"
-	TransactionStatusRejected   = "rejected"
+	TransactionStatusNew          = "new"
+	TransactionStatusPendingCheck = "pending_check"
+	TransactionStatusInProgress   = "in_progress"
+	TransactionStatusCompleted    = "completed"
+	TransactionStatusRejected     = "rejected"
+	TransactionStatusRefunded     = "refunded"
+	TransactionStatusManualReview = "manual_review"
+)
+
+var (
+	// Allowed transitions between transaction statuses
+	transactionTransitions = map[string][]string{
+		TransactionStatusNew: {
+			TransactionStatusPendingCheck,
+			TransactionStatusInProgress,
+			TransactionStatusCompleted,
+			TransactionStatusRejected,
+			TransactionStatusManualReview,
+		},
+		TransactionStatusPendingCheck: {
+			TransactionStatusInProgress,
+			TransactionStatusCompleted,
+			TransactionStatusRejected,
+			TransactionStatusManualReview,
+		},
+		TransactionStatusInProgress: {
+			TransactionStatusCompleted,
+			TransactionStatusRejected,
+			TransactionStatusManualReview,
+		},
+		TransactionStatusManualReview: {
+			TransactionStatusInProgress,
+			TransactionStatusCompleted,
+			TransactionStatusRejected,
+		},
+		TransactionStatusCompleted: {
+			TransactionStatusRefunded,
+		},
+	}
 )
 
 type Transaction struct {
@@ -35,57 +75,122 @@ type Transaction struct {
 	ProviderHandlerId string `db:"provider_handler_id"`
 	// The transaction unique identifier in client billing system.
 	ClientTxnId *string `db:"client_txn_id"`
+	// The hash of client request by which transaction was created.
+	// It's used to check that repeated request with same client transaction identifier is identical to first one.
+	RequestHash string `db:"request_hash"`
 	// The transaction unique identifier in provider billing system.
 	ProviderTxnId *string `db:"provider_txn_id"`
 	// The customer's account in service into which sending payment amount.
 	Account string `db:"account"`
 	// The key-value object that you can attach to the payment request.
 	// It can be useful for storing additional information about your customer’s payment.
-	Metadata map[string]interface{} `db:"metadata"`
+	Metadata Metadata `db:"metadata"`
 	// The description for payment to show to customer in payment details in account statement.
 	Description string `db:"description"`
 	// The payment amount which was received from the client.
-	IncomeAmount float32 `db:"income_amount"`
+	IncomeAmount pkg.Amount `db:"income_amount"`
 	// The currency of client's balance.
 	IncomeCurrency string `db:"income_currency"`
 	// The fee amount from client for payment in currency which was received from the client.
-	ClientFeeInIncomeCurrency float32 `db:"client_fee_in_income_currency"`
+	ClientFeeInIncomeCurrency pkg.Amount `db:"client_fee_in_income_currency"`
 	// The fee amount from customer for payment in currency which was received from the client.
-	CustomerFeeInIncomeCurrency float32 `db:"customer_fee_in_income_currency"`
+	CustomerFeeInIncomeCurrency pkg.Amount `db:"customer_fee_in_income_currency"`
 	// The payment amount which will be send to provider.
-	OutcomeAmount float32 `db:"outcome_amount"`
+	OutcomeAmount pkg.Amount `db:"outcome_amount"`
 	// The provider's currency.
 	OutcomeCurrency string `db:"outcome_currency"`
 	// The fee amount from client for payment in currency which will be send payment to provider.
-	ClientFeeInOutcomeCurrency float32 `db:"client_fee_in_outcome_currency"`
+	ClientFeeInOutcomeCurrency pkg.Amount `db:"client_fee_in_outcome_currency"`
 	// The fee amount from customer for payment in currency which will be send payment to provider.
-	CustomerFeeInOutcomeCurrency float32 `db:"customer_fee_in_outcome_currency"`
+	CustomerFeeInOutcomeCurrency pkg.Amount `db:"customer_fee_in_outcome_currency"`
 	// The payment amount in system accounting currency.
-	AccountingAmount float32 `db:"accounting_amount"`
+	AccountingAmount pkg.Amount `db:"accounting_amount"`
 	// The system accounting currency.
 	AccountingCurrency string `db:"accounting_currency"`
 	// The fee amount from client for payment in system accounting currency.
-	ClientFeeInAccountingCurrency float32 `db:"client_fee_in_accounting_currency"`
+	ClientFeeInAccountingCurrency pkg.Amount `db:"client_fee_in_accounting_currency"`
 	// The fee amount from customer for payment in system accounting currency.
-	CustomerFeeInAccountingCurrency float32 `db:"customer_fee_in_accounting_currency"`
+	CustomerFeeInAccountingCurrency pkg.Amount `db:"customer_fee_in_accounting_currency"`
 	// The conversion rate value from income currency to outcome currency.
-	IncomeToOutcomeRate float32 `db:"income_to_outcome_rate"`
+	IncomeToOutcomeRate pkg.Rate `db:"income_to_outcome_rate"`
 	// The conversion rate value from income currency to accounting currency.
-	IncomeToAccountingRate float32 `db:"income_to_accounting_rate"`
+	IncomeToAccountingRate pkg.Rate `db:"income_to_accounting_rate"`
 	// The conversion rate value from outcome currency to accounting currency.
-	OutcomeToAccountingRate float32 `db:"outcome_to_accounting_rate"`
+	OutcomeToAccountingRate pkg.Rate `db:"outcome_to_accounting_rate"`
+	// The copies of courses which were used to calculate conversion rates of transaction.
+	CourseSnapshots CourseSnapshots `db:"course_snapshots"`
 	// The transaction reject reason.
 	GatewayRejectReason string `db:"gateway_reject_reason"`
 	// The transaction status.
 	Status string `db:"status"`
 	// The client balance before transaction
-	ClientBalanceBefore float32 `db:"client_balance_before"`
+	ClientBalanceBefore pkg.Amount `db:"client_balance_before"`
 	// The client balance after transaction
-	ClientBalanceAfter float32 `db:"client_balance_after"`
+	ClientBalanceAfter pkg.Amount `db:"client_balance_after"`
+	// The transaction version, it's incremented by each transaction status change.
+	Version int64 `db:"version"`
+}
+
+type TransactionStatusChange struct {
+	Id            uint64    `db:"id"`
+	TransactionId uint64    `db:"transaction_id"`
+	FromStatus    string    `db:"from_status"`
+	ToStatus      string    `db:"to_status"`
+	Reason        string    `db:"reason"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// StaleTransaction is a transaction which processing is interrupted with identifier of its service in provider's
+// billing system to request transaction status from provider
+type StaleTransaction struct {
+	Transaction
+	ServiceExternalId string `db:"service_external_id"`
+}
+
+// VelocityLimit is a limit of payments amount in provider currency into service account since received time
+type VelocityLimit struct {
+	// The limit period name, i.e. daily
+	Name  string
+	Limit pkg.Amount
+	Since time.Time
+}
+
+// VelocityLimitError is returned when transaction amount exceeds payments amount limit of service account
+type VelocityLimitError struct {
+	Limit *VelocityLimit
+	// The amount which still can be paid into account in the limit period
+	Available pkg.Amount
+}
+
+func (m *VelocityLimitError) Error() string {
+	return m.Limit.Name + " payments amount limit of account exceeded"
 }
 
 type transactionRepository repository
 
+// Metadata is the key-value object which is stored in database as JSON
+type Metadata map[string]interface{}
+
+var (
+	errTransactionExists = errors.New("transaction with same client transaction identifier already exists")
+)
+
+const (
+	transactionColumns = "id, uuid, client_id, client_name, provider_id, provider_name, service_id, service_name, " +
+		"provider_handler_id, client_txn_id, request_hash, provider_txn_id, account, metadata, description, income_amount, " +
+		"income_currency, client_fee_in_income_currency, customer_fee_in_income_currency, outcome_amount, " +
+		"outcome_currency, client_fee_in_outcome_currency, customer_fee_in_outcome_currency, accounting_amount, " +
+		"accounting_currency, client_fee_in_accounting_currency, customer_fee_in_accounting_currency, " +
+		"income_to_outcome_rate, income_to_accounting_rate, outcome_to_accounting_rate, course_snapshots, " +
+		"gateway_reject_reason, status, client_balance_before, client_balance_after, version, created_at, updated_at, deleted_at"
+
+	balanceQuery = "SELECT balance FROM merchants WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	// The lock of service account is held until the end of database transaction, so concurrent payments into the
+	// same account are checked by velocity limits one by one
+	accountLockQuery   = "SELECT pg_advisory_xact_lock(hashtext($1::TEXT), hashtext($2))"
+	balanceUpdateQuery = "UPDATE merchants SET balance = balance + $1, updated_at = now() WHERE id = $2"
+)
+
 func newTransactionRepository(
 	db *sqlx.DB,
 	logger *zap.Logger,
@@ -102,15 +207,334 @@ func (m *transactionRepository) GetTransactionByClientTxnId(
 	clientId uint64,
 	clientTxnId string,
 ) (*Transaction, error) {
-	transaction := new(Transaction)
-	query := "SELECT client_id, client_name, provider_id, provider_name, service_id, service_name, provider_handler_id, " +
-		"client_txn_id, provider_txn_id, account, metadata, description, income_amount, income_currency, " +
+	query := "SELECT " + transactionColumns + " FROM transactions " +
+		"WHERE client_id = $1 AND client_txn_id = $2 AND deleted_at IS NULL"
+	return m.getTransaction(ctx, query, clientId, clientTxnId)
+}
+
+func (m *transactionRepository) GetTransactionById(ctx context.Context, id uint64) (*Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = $1 AND deleted_at IS NULL"
+	return m.getTransaction(ctx, query, id)
+}
+
+func (m *transactionRepository) GetTransactionByProviderTxnId(
+	ctx context.Context,
+	handlerId string,
+	providerTxnId string,
+) (*Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions " +
+		"WHERE provider_handler_id = $1 AND provider_txn_id = $2 AND deleted_at IS NULL"
+	return m.getTransaction(ctx, query, handlerId, providerTxnId)
+}
+
+// Create creates transaction and reserves transaction amount with client fee on client balance in one database
+// transaction, error is returned if client balance is insufficient. Payments into service account are checked by
+// velocity limits under lock of account in the same database transaction, *VelocityLimitError is returned if limit
+// is exceeded. If transaction with same client transaction identifier already exists then existing transaction
+// is returned when its request hash equals to request hash of new transaction, otherwise error is returned.
+// Flag of transaction creation is returned together with transaction.
+func (m *transactionRepository) Create(
+	ctx context.Context,
+	in *Transaction,
+	limits []*VelocityLimit,
+) (*Transaction, bool, error) {
+	query := "INSERT INTO transactions (client_id, client_name, provider_id, provider_name, service_id, service_name, " +
+		"provider_handler_id, client_txn_id, request_hash, account, metadata, description, income_amount, income_currency, " +
 		"client_fee_in_income_currency, customer_fee_in_income_currency, outcome_amount, outcome_currency, " +
 		"client_fee_in_outcome_currency, customer_fee_in_outcome_currency, accounting_amount, accounting_currency, " +
-		"client_fee_in_accounting_currency, customer_fee_in_accounting_currency, income_to_outcome_rate, income_to_accounting, " +
-		"outcome_to_accounting, gateway_reject_reason, status, balance_before, balance_after, created_at, updated_at, deleted_at " +
-		"FROM transactions WHERE client_id = $1 AND client_txn_id = $2 AND deleted_at IS NULL"
-	args := []interface{}{clientId, clientTxnId}
+		"client_fee_in_accounting_currency, customer_fee_in_accounting_currency, income_to_outcome_rate, " +
+		"income_to_accounting_rate, outcome_to_accounting_rate, course_snapshots, status, client_balance_before, " +
+		"client_balance_after) " +
+		"VALUES (:client_id, :client_name, :provider_id, :provider_name, :service_id, :service_name, " +
+		":provider_handler_id, :client_txn_id, :request_hash, :account, :metadata, :description, :income_amount, :income_currency, " +
+		":client_fee_in_income_currency, :customer_fee_in_income_currency, :outcome_amount, :outcome_currency, " +
+		":client_fee_in_outcome_currency, :customer_fee_in_outcome_currency, :accounting_amount, :accounting_currency, " +
+		":client_fee_in_accounting_currency, :customer_fee_in_accounting_currency, :income_to_outcome_rate, " +
+		":income_to_accounting_rate, :outcome_to_accounting_rate, :course_snapshots, :status, :client_balance_before, " +
+		":client_balance_after) ON CONFLICT (client_id, client_txn_id) DO NOTHING " +
+		"RETURNING id, uuid, created_at, updated_at"
+
+	if in.Status == "" {
+		in.Status = TransactionStatusNew
+	}
+
+	created := false
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := runInTx(ctx, m.db, opts, func(tx *sqlx.Tx) error {
+		created = false
+
+		if err := checkVelocityLimits(ctx, tx, in, limits); err != nil {
+			return err
+		}
+
+		balance := pkg.Amount(0)
+		err := tx.GetContext(ctx, &balance, balanceQuery, in.ClientId)
+
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return pkg.ErrorMerchantNotFound
+			}
+
+			return err
+		}
+
+		in.ClientBalanceBefore = balance
+		in.ClientBalanceAfter = balance - in.IncomeAmount - in.ClientFeeInIncomeCurrency
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, in)
+
+		if err != nil {
+			return err
+		}
+
+		if rows.Next() {
+			err = rows.Scan(&in.Id, &in.Uuid, &in.CreatedAt, &in.UpdatedAt)
+		} else if err = rows.Err(); err == nil {
+			err = errTransactionExists
+		}
+
+		_ = rows.Close()
+
+		if err != nil {
+			return err
+		}
+
+		if in.ClientBalanceAfter < 0 {
+			return pkg.ErrorInsufficientFunds
+		}
+
+		_, err = tx.ExecContext(ctx, balanceUpdateQuery, -(in.IncomeAmount + in.ClientFeeInIncomeCurrency), in.ClientId)
+
+		if err == nil {
+			created = true
+		}
+
+		return err
+	})
+
+	if err == nil {
+		return in, created, nil
+	}
+
+	if err == errTransactionExists && in.ClientTxnId != nil {
+		existing, err := m.GetTransactionByClientTxnId(ctx, in.ClientId, *in.ClientTxnId)
+
+		if err != nil || existing == nil {
+			return nil, false, pkg.ErrorUnknown
+		}
+
+		if existing.RequestHash != in.RequestHash {
+			return nil, false, pkg.ErrorTransactionConflict
+		}
+
+		return existing, false, nil
+	}
+
+	switch e := err.(type) {
+	case *pkg.Error:
+		return nil, false, e
+	case *VelocityLimitError:
+		return nil, false, e
+	}
+
+	m.logger.Error(
+		pkg.ErrorDatabaseQueryFailed,
+		zap.Error(err),
+		zap.String(pkg.ErrorDatabaseFieldFilter, query),
+		zap.Any(pkg.ErrorDatabaseFieldArguments, in),
+	)
+	return nil, false, pkg.ErrorUnknown
+}
+
+// SetInProgress sets in progress status and provider transaction identifier to transaction, current provider
+// transaction identifier is kept if received identifier is empty
+func (m *transactionRepository) SetInProgress(ctx context.Context, txn *Transaction, providerTxnId string) error {
+	return m.transit(ctx, txn, TransactionStatusInProgress, "", func(next *Transaction) {
+		if providerTxnId != "" {
+			next.ProviderTxnId = &providerTxnId
+		}
+	})
+}
+
+// Complete sets completed status and provider transaction identifier to transaction, current provider transaction
+// identifier is kept if received identifier is empty
+func (m *transactionRepository) Complete(ctx context.Context, txn *Transaction, providerTxnId string) error {
+	return m.transit(ctx, txn, TransactionStatusCompleted, "", func(next *Transaction) {
+		if providerTxnId != "" {
+			next.ProviderTxnId = &providerTxnId
+		}
+	})
+}
+
+// Reject sets rejected status, provider transaction identifier and reject reason to transaction, current provider
+// transaction identifier is kept if received identifier is empty
+func (m *transactionRepository) Reject(ctx context.Context, txn *Transaction, providerTxnId, reason string) error {
+	return m.transit(ctx, txn, TransactionStatusRejected, reason, func(next *Transaction) {
+		if providerTxnId != "" {
+			next.ProviderTxnId = &providerTxnId
+		}
+
+		next.GatewayRejectReason = reason
+	})
+}
+
+// Transit changes transaction status if transition from current transaction status is allowed and writes
+// transition to transaction status history, notification to client is queued when status becomes final.
+// Transaction is updated only if its version wasn't changed since transaction was read, otherwise version conflict
+// error is returned and transaction must be read again. Received transaction is changed only if status was changed.
+func (m *transactionRepository) Transit(ctx context.Context, txn *Transaction, status, reason string) error {
+	return m.transit(ctx, txn, status, reason, nil)
+}
+
+// transit changes transaction status together with transaction fields changed by change function, change function
+// receives copy of transaction which replaces received transaction after successful change
+func (m *transactionRepository) transit(
+	ctx context.Context,
+	txn *Transaction,
+	status, reason string,
+	change func(next *Transaction),
+) error {
+	if !CanTransit(txn.Status, status) {
+		if IsFinalStatus(txn.Status) {
+			return pkg.ErrorTransactionAlreadyFinished
+		}
+
+		return pkg.ErrorTransactionTransitionForbidden
+	}
+
+	next := *txn
+
+	if change != nil {
+		change(&next)
+	}
+
+	current := time.Now()
+	query := "UPDATE transactions SET status = $1, provider_txn_id = $2, gateway_reject_reason = $3, " +
+		"version = version + 1, updated_at = $4 WHERE id = $5 AND version = $6"
+	args := []interface{}{status, next.ProviderTxnId, next.GatewayRejectReason, current, txn.Id, txn.Version}
+	err := runInTx(ctx, m.db, nil, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			return pkg.ErrorTransactionVersionConflict
+		}
+
+		historyQuery := "INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, " +
+			"created_at) VALUES ($1, $2, $3, $4, $5)"
+
+		if _, err = tx.ExecContext(ctx, historyQuery, txn.Id, txn.Status, status, reason, current); err != nil {
+			return err
+		}
+
+		// reserved funds are returned to client balance when transaction wasn't paid or payment was refunded
+		if status == TransactionStatusRejected || status == TransactionStatusRefunded {
+			_, err = tx.ExecContext(ctx, balanceUpdateQuery, txn.IncomeAmount+txn.ClientFeeInIncomeCurrency, txn.ClientId)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if status == TransactionStatusCompleted || status == TransactionStatusRefunded {
+			entries, err := NewTransactionEntries(&next)
+
+			if err != nil {
+				return err
+			}
+
+			if status == TransactionStatusRefunded {
+				entries = ReverseEntries(entries)
+			}
+
+			if err = postAccountingEntries(ctx, tx, entries, current); err != nil {
+				return err
+			}
+		}
+
+		if err != nil || !IsFinalStatus(status) {
+			return err
+		}
+
+		// notification is queued with status change, so it isn't lost if process fails after status change
+		finished := next
+		finished.Status = status
+
+		return createNotification(ctx, tx, &finished, current)
+	})
+
+	if err != nil {
+		if e, ok := err.(*pkg.Error); ok {
+			return e
+		}
+
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return pkg.ErrorUnknown
+	}
+
+	next.Status = status
+	next.Version++
+	next.UpdatedAt = current
+	*txn = next
+
+	return nil
+}
+
+func (m *transactionRepository) GetStatusHistory(ctx context.Context, txnId uint64) ([]*TransactionStatusChange, error) {
+	query := "SELECT id, transaction_id, from_status, to_status, reason, created_at FROM transaction_status_history " +
+		"WHERE transaction_id = $1 ORDER BY id"
+	args := []interface{}{txnId}
+	history := make([]*TransactionStatusChange, 0)
+	err := m.db.SelectContext(ctx, &history, query, args...)
+
+	if err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return history, nil
+}
+
+// GetStaleTransactions returns transactions which weren't sent to provider or which account check wasn't finished
+// and which weren't changed since received time, transactions are ordered from the oldest change
+func (m *transactionRepository) GetStaleTransactions(
+	ctx context.Context,
+	updatedBefore time.Time,
+	limit int,
+) ([]*StaleTransaction, error) {
+	query := "SELECT " + transactionColumns + ", (SELECT external_id FROM services WHERE id = transactions.service_id) " +
+		"AS service_external_id FROM transactions WHERE status IN ($1, $2) AND updated_at < $3 " +
+		"AND deleted_at IS NULL ORDER BY updated_at LIMIT $4"
+	args := []interface{}{TransactionStatusNew, TransactionStatusPendingCheck, updatedBefore, limit}
+	transactions := make([]*StaleTransaction, 0, limit)
+
+	if err := m.db.SelectContext(ctx, &transactions, query, args...); err != nil {
+		m.logger.Error(
+			pkg.ErrorDatabaseQueryFailed,
+			zap.Error(err),
+			zap.String(pkg.ErrorDatabaseFieldFilter, query),
+			zap.Any(pkg.ErrorDatabaseFieldArguments, args),
+		)
+		return nil, pkg.ErrorUnknown
+	}
+
+	return transactions, nil
+}
+
+func (m *transactionRepository) getTransaction(ctx context.Context, query string, args ...interface{}) (*Transaction, error) {
+	transaction := new(Transaction)
 	err := m.db.GetContext(ctx, transaction, query, args...)
 
 	if err != nil {
@@ -130,32 +554,97 @@ func (m *transactionRepository) GetTransactionByClientTxnId(
 	return transaction, nil
 }
 
-func (m *transactionRepository) Create(ctx context.Context, in *Transaction) (*Transaction, error) {
-	opts := &sql.TxOptions{
-		Isolation: sql.LevelSerializable,
-	}
-	txn, err := m.db.BeginTx(ctx, opts)
+// Income returns payment amount which was received from the client in client's currency
+func (m *Transaction) Income() *pkg.Money {
+	return pkg.NewMoney(m.IncomeAmount, m.IncomeCurrency)
+}
 
-	if err != nil {
-		return nil, err
+// Outcome returns payment amount which will be send to provider in provider's currency
+func (m *Transaction) Outcome() *pkg.Money {
+	return pkg.NewMoney(m.OutcomeAmount, m.OutcomeCurrency)
+}
+
+// CustomerFee returns fee amount from customer in provider's currency
+func (m *Transaction) CustomerFee() *pkg.Money {
+	return pkg.NewMoney(m.CustomerFeeInOutcomeCurrency, m.OutcomeCurrency)
+}
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
 	}
 
-	clientBalanceBefore := float32(0)
-	clientBalanceAfter := float32(0)
-	clientFeeInIncomeCurrency := float32(0)
-	ClientFeeInOutcomeCurrency := float32(0)
-	ClientFeeInAccountingCurrency := float32(0)
+	return json.Marshal(m)
+}
+
+func (m *Metadata) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
 
-	query := "SELECT fee_percent, balance FROM client WHERE id = $1"
-	err = txn.QueryRow("SELECT $1 * (fee_percent/100), balance - ($2 + $3 * (balance/100)), balance FROM leo_paymethods WHERE id = ?",
-		in.InAmount, in.InAmount, in.InAmount, in.Client.Id).
-		Scan(&commission, &balanceAfter, &balance)
+	return fmt.Errorf("unsupported metadata type %T", src)
 }
 
-func (m *transactionRepository) Complete(txn *Transaction) error {
+// CanTransit checks that transaction status can be changed from one status to other
+func CanTransit(from, to string) bool {
+	for _, status := range transactionTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
 
+// IsFinalStatus checks that transaction with received status can't be processed anymore
+func IsFinalStatus(status string) bool {
+	return status == TransactionStatusCompleted || status == TransactionStatusRejected ||
+		status == TransactionStatusRefunded
 }
 
-func (m *transactionRepository) Reject(txn *Transaction) error {
+// checkVelocityLimits locks service account of transaction and checks that payments amount into account together
+// with transaction amount doesn't exceed limits, rejected and refunded payments are excluded from payments amount
+func checkVelocityLimits(ctx context.Context, tx *sqlx.Tx, txn *Transaction, limits []*VelocityLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, accountLockQuery, txn.ServiceId, txn.Account)
+
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT COALESCE(SUM(outcome_amount), 0)::BIGINT FROM transactions WHERE service_id = $1 AND account = $2 " +
+		"AND created_at >= $3 AND status NOT IN ($4, $5) AND deleted_at IS NULL"
+
+	for _, limit := range limits {
+		turnover := pkg.Amount(0)
+		args := []interface{}{txn.ServiceId, txn.Account, limit.Since, TransactionStatusRejected, TransactionStatusRefunded}
+		err = tx.GetContext(ctx, &turnover, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		if turnover+txn.OutcomeAmount <= limit.Limit {
+			continue
+		}
+
+		available := limit.Limit - turnover
+
+		if available < 0 {
+			available = 0
+		}
+
+		return &VelocityLimitError{Limit: limit, Available: available}
+	}
 
+	return nil
 }
@@ -2,14 +2,161 @@ package main
 
 import (
 	"context"
-	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"github.com/sidmal/ianua/internal/api"
+	"github.com/sidmal/ianua/internal/conversion"
+	"github.com/sidmal/ianua/internal/gateway"
+	"github.com/sidmal/ianua/internal/notifier"
+	"github.com/sidmal/ianua/internal/payment"
+	"github.com/sidmal/ianua/internal/repository"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultHttpAddr           = ":8080"
+	defaultCacheLifetime      = 60
+	defaultCacheMaxSize       = 10000
+	defaultProcessTimeout     = 5 * time.Minute
+	defaultReconcileInterval  = time.Minute
+	defaultShutdownTimeout    = 30 * time.Second
+	defaultNotifierBatchSize  = 100
+	defaultClockSkew          = 5 * time.Minute
+	defaultAccountingCurrency = "USD"
+	defaultDbMaxOpenConns     = 20
+	defaultDbMaxIdleConns     = 10
+	defaultDbConnMaxLifetime  = 1800
+	defaultDbConnMaxIdleTime  = 300
+	defaultDbConnectTimeout   = 10 * time.Second
 )
 
 func main() {
-	logger := log15adapter.NewLogger(log.New("module", "pgx"))
-	poolConfig, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
-	poolConfig.ConnConfig.Logger = logger
+	logger, err := zap.NewProduction()
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	dbOpts := &repository.DatabaseOptions{
+		Url:             os.Getenv("DATABASE_URL"),
+		MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", defaultDbMaxOpenConns),
+		MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", defaultDbMaxIdleConns),
+		ConnMaxLifetime: time.Duration(getEnvInt("DB_CONN_MAX_LIFETIME", defaultDbConnMaxLifetime)) * time.Second,
+		ConnMaxIdleTime: time.Duration(getEnvInt("DB_CONN_MAX_IDLE_TIME", defaultDbConnMaxIdleTime)) * time.Second,
+		ConnectTimeout:  defaultDbConnectTimeout,
+	}
+	db, err := repository.Connect(context.Background(), dbOpts)
+
+	if err != nil {
+		logger.Fatal("database connection failed", zap.Error(err))
+	}
+
+	defer db.Close()
+
+	if err = repository.LoadCurrencyExponents(context.Background(), db); err != nil {
+		logger.Fatal("currencies loading failed", zap.Error(err))
+	}
+
+	gateways, err := gateway.LoadGateways(os.Getenv("GATEWAYS_CONFIG"), logger)
+
+	if err != nil {
+		logger.Fatal("gateways loading failed", zap.Error(err))
+	}
+
+	cacheLifetime := &repository.CacheLifetime{
+		Course:  getEnvInt("CACHE_LIFETIME_COURSE", defaultCacheLifetime),
+		Client:  getEnvInt("CACHE_LIFETIME_CLIENT", defaultCacheLifetime),
+		Project: getEnvInt("CACHE_LIFETIME_PROJECT", defaultCacheLifetime),
+		MaxSize: getEnvInt("CACHE_MAX_SIZE", defaultCacheMaxSize),
+	}
+	repo := repository.NewRepository(db, cacheLifetime, logger)
+
+	notifierOpts := &notifier.Options{
+		PollInterval:   5 * time.Second,
+		BatchSize:      defaultNotifierBatchSize,
+		MaxAttempts:    getEnvInt("NOTIFIER_MAX_ATTEMPTS", 10),
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Hour,
+		Timeout:        10 * time.Second,
+	}
+	clientNotifier := notifier.NewNotifier(repo, notifierOpts, logger)
+	accountingCurrency := getEnv("ACCOUNTING_CURRENCY", defaultAccountingCurrency)
+	converter := conversion.NewConverter(
+		repo.GetCourseRepository(),
+		getEnv("BASE_CURRENCY", accountingCurrency),
+		accountingCurrency,
+	)
+	processor := payment.NewProcessor(repo, gateways, converter, defaultProcessTimeout, logger)
+
+	addr := os.Getenv("HTTP_ADDR")
+
+	if addr == "" {
+		addr = defaultHttpAddr
+	}
+
+	handler := api.NewApi(repo, gateways, processor, defaultClockSkew, os.Getenv("ADMIN_TOKEN"), logger)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go clientNotifier.Run(ctx)
+	go processor.RunReconciler(ctx, defaultReconcileInterval)
+	go handler.RunNonceCleaner(ctx, defaultClockSkew)
+	go repository.NewInvalidationListener(repo, os.Getenv("DATABASE_URL"), logger).Run(ctx)
+
+	go func() {
+		logger.Info("http server started", zap.String("addr", addr))
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("http server failed", zap.Error(err))
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer shutdownCancel()
+
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http server shutdown failed", zap.Error(err))
+	}
+
+	// payments which processing isn't finished until timeout are finished by reconciler after restart
+	if err = processor.Wait(shutdownCtx); err != nil {
+		logger.Error("payments processing waiting failed", zap.Error(err))
+	}
+}
+
+func getEnv(name, def string) string {
+	if val := os.Getenv(name); val != "" {
+		return val
+	}
+
+	return def
+}
+
+func getEnvInt(name string, def int) int {
+	val, err := strconv.Atoi(os.Getenv(name))
+
+	if err != nil {
+		return def
+	}
 
-	db, err = pgxpool.ConnectConfig(context.Background(), poolConfig)
+	return val
 }
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE merchants DROP COLUMN IF EXISTS callback_url;
@@ -0,0 +1,33 @@
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS callback_url TEXT;
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id              BIGSERIAL PRIMARY KEY,
+    uuid            UUID        NOT NULL DEFAULT gen_random_uuid(),
+    client_id       BIGINT      NOT NULL REFERENCES merchants (id),
+    transaction_id  BIGINT      NOT NULL REFERENCES transactions (id),
+    url             TEXT        NOT NULL,
+    payload         BYTEA       NOT NULL,
+    status          VARCHAR(16) NOT NULL,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS notifications_status_next_attempt_at_idx ON notifications (status, next_attempt_at)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    notification_id BIGINT      NOT NULL REFERENCES notifications (id),
+    attempt         INTEGER     NOT NULL,
+    response_status INTEGER     NOT NULL DEFAULT 0,
+    response_body   TEXT        NOT NULL DEFAULT '',
+    error           TEXT        NOT NULL DEFAULT '',
+    -- delivery duration in nanoseconds
+    duration        BIGINT      NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliveries_notification_id_idx ON notification_deliveries (notification_id);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP CONSTRAINT transactions_client_id_client_txn_id_key;
+ALTER TABLE transactions DROP COLUMN request_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions ADD COLUMN request_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD CONSTRAINT transactions_client_id_client_txn_id_key UNIQUE (client_id, client_txn_id);
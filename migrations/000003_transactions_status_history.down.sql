@@ -0,0 +1,2 @@
+DROP TABLE transaction_status_history;
+ALTER TABLE transactions DROP COLUMN version;
@@ -0,0 +1,12 @@
+ALTER TABLE transactions ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE transaction_status_history (
+    id             BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT       NOT NULL REFERENCES transactions (id),
+    from_status    VARCHAR(32)  NOT NULL,
+    to_status      VARCHAR(32)  NOT NULL,
+    reason         TEXT         NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX transaction_status_history_transaction_id_idx ON transaction_status_history (transaction_id);
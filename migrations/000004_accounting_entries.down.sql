@@ -0,0 +1 @@
+DROP TABLE IF EXISTS accounting_entries;
@@ -0,0 +1,16 @@
+CREATE TABLE accounting_entries (
+    id                  BIGSERIAL PRIMARY KEY,
+    transaction_id      BIGINT         NOT NULL REFERENCES transactions (id),
+    account             VARCHAR(64)    NOT NULL,
+    owner_id            BIGINT         NOT NULL DEFAULT 0,
+    debit               NUMERIC(20, 6) NOT NULL DEFAULT 0,
+    credit              NUMERIC(20, 6) NOT NULL DEFAULT 0,
+    currency            CHAR(3)        NOT NULL,
+    accounting_debit    NUMERIC(20, 6) NOT NULL DEFAULT 0,
+    accounting_credit   NUMERIC(20, 6) NOT NULL DEFAULT 0,
+    accounting_currency CHAR(3)        NOT NULL,
+    created_at          TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX accounting_entries_transaction_id_idx ON accounting_entries (transaction_id);
+CREATE INDEX accounting_entries_account_idx ON accounting_entries (account, owner_id, currency, created_at);
@@ -0,0 +1,46 @@
+ALTER TABLE accounting_entries
+    ALTER COLUMN debit TYPE NUMERIC(20, 6) USING debit / power(10, currency_exponent(currency)),
+    ALTER COLUMN credit TYPE NUMERIC(20, 6) USING credit / power(10, currency_exponent(currency)),
+    ALTER COLUMN accounting_debit TYPE NUMERIC(20, 6)
+        USING accounting_debit / power(10, currency_exponent(accounting_currency)),
+    ALTER COLUMN accounting_credit TYPE NUMERIC(20, 6)
+        USING accounting_credit / power(10, currency_exponent(accounting_currency));
+
+ALTER TABLE transactions
+    ALTER COLUMN income_amount TYPE NUMERIC(20, 6)
+        USING income_amount / power(10, currency_exponent(income_currency)),
+    ALTER COLUMN client_fee_in_income_currency TYPE NUMERIC(20, 6)
+        USING client_fee_in_income_currency / power(10, currency_exponent(income_currency)),
+    ALTER COLUMN customer_fee_in_income_currency TYPE NUMERIC(20, 6)
+        USING customer_fee_in_income_currency / power(10, currency_exponent(income_currency)),
+    ALTER COLUMN client_balance_before TYPE NUMERIC(20, 6)
+        USING client_balance_before / power(10, currency_exponent(income_currency)),
+    ALTER COLUMN client_balance_after TYPE NUMERIC(20, 6)
+        USING client_balance_after / power(10, currency_exponent(income_currency)),
+    ALTER COLUMN outcome_amount TYPE NUMERIC(20, 6)
+        USING outcome_amount / power(10, currency_exponent(outcome_currency)),
+    ALTER COLUMN client_fee_in_outcome_currency TYPE NUMERIC(20, 6)
+        USING client_fee_in_outcome_currency / power(10, currency_exponent(outcome_currency)),
+    ALTER COLUMN customer_fee_in_outcome_currency TYPE NUMERIC(20, 6)
+        USING customer_fee_in_outcome_currency / power(10, currency_exponent(outcome_currency)),
+    ALTER COLUMN accounting_amount TYPE NUMERIC(20, 6)
+        USING accounting_amount / power(10, currency_exponent(accounting_currency)),
+    ALTER COLUMN client_fee_in_accounting_currency TYPE NUMERIC(20, 6)
+        USING client_fee_in_accounting_currency / power(10, currency_exponent(accounting_currency)),
+    ALTER COLUMN customer_fee_in_accounting_currency TYPE NUMERIC(20, 6)
+        USING customer_fee_in_accounting_currency / power(10, currency_exponent(accounting_currency));
+
+ALTER TABLE services
+    ALTER COLUMN min_amount TYPE NUMERIC(20, 6),
+    ALTER COLUMN max_amount TYPE NUMERIC(20, 6);
+
+UPDATE services s
+SET min_amount = s.min_amount / power(10, currency_exponent(p.currency)),
+    max_amount = s.max_amount / power(10, currency_exponent(p.currency))
+FROM providers p
+WHERE p.id = s.provider_id;
+
+ALTER TABLE merchants
+    ALTER COLUMN balance TYPE NUMERIC(20, 6) USING balance / power(10, currency_exponent(currency));
+
+DROP FUNCTION currency_exponent(CHAR(3));
@@ -0,0 +1,59 @@
+CREATE FUNCTION currency_exponent(code CHAR(3)) RETURNS INTEGER AS $$
+    SELECT CASE
+        WHEN code IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND',
+                      'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+        WHEN code IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+        ELSE 2
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE merchants
+    ALTER COLUMN balance TYPE BIGINT USING round(balance * power(10, currency_exponent(currency)));
+
+ALTER TABLE services ADD COLUMN min_amount_minor BIGINT NOT NULL DEFAULT 0,
+                     ADD COLUMN max_amount_minor BIGINT NOT NULL DEFAULT 0;
+
+UPDATE services s
+SET min_amount_minor = round(s.min_amount * power(10, currency_exponent(p.currency))),
+    max_amount_minor = round(s.max_amount * power(10, currency_exponent(p.currency)))
+FROM providers p
+WHERE p.id = s.provider_id;
+
+ALTER TABLE services DROP COLUMN min_amount, DROP COLUMN max_amount;
+ALTER TABLE services RENAME COLUMN min_amount_minor TO min_amount;
+ALTER TABLE services RENAME COLUMN max_amount_minor TO max_amount;
+
+ALTER TABLE transactions
+    ALTER COLUMN income_amount TYPE BIGINT
+        USING round(income_amount * power(10, currency_exponent(income_currency))),
+    ALTER COLUMN client_fee_in_income_currency TYPE BIGINT
+        USING round(client_fee_in_income_currency * power(10, currency_exponent(income_currency))),
+    ALTER COLUMN customer_fee_in_income_currency TYPE BIGINT
+        USING round(customer_fee_in_income_currency * power(10, currency_exponent(income_currency))),
+    ALTER COLUMN client_balance_before TYPE BIGINT
+        USING round(client_balance_before * power(10, currency_exponent(income_currency))),
+    ALTER COLUMN client_balance_after TYPE BIGINT
+        USING round(client_balance_after * power(10, currency_exponent(income_currency))),
+    ALTER COLUMN outcome_amount TYPE BIGINT
+        USING round(outcome_amount * power(10, currency_exponent(outcome_currency))),
+    ALTER COLUMN client_fee_in_outcome_currency TYPE BIGINT
+        USING round(client_fee_in_outcome_currency * power(10, currency_exponent(outcome_currency))),
+    ALTER COLUMN customer_fee_in_outcome_currency TYPE BIGINT
+        USING round(customer_fee_in_outcome_currency * power(10, currency_exponent(outcome_currency))),
+    ALTER COLUMN accounting_amount TYPE BIGINT
+        USING round(accounting_amount * power(10, currency_exponent(accounting_currency))),
+    ALTER COLUMN client_fee_in_accounting_currency TYPE BIGINT
+        USING round(client_fee_in_accounting_currency * power(10, currency_exponent(accounting_currency))),
+    ALTER COLUMN customer_fee_in_accounting_currency TYPE BIGINT
+        USING round(customer_fee_in_accounting_currency * power(10, currency_exponent(accounting_currency))),
+    ALTER COLUMN income_to_outcome_rate TYPE NUMERIC(20, 6),
+    ALTER COLUMN income_to_accounting_rate TYPE NUMERIC(20, 6),
+    ALTER COLUMN outcome_to_accounting_rate TYPE NUMERIC(20, 6);
+
+ALTER TABLE accounting_entries
+    ALTER COLUMN debit TYPE BIGINT USING round(debit * power(10, currency_exponent(currency))),
+    ALTER COLUMN credit TYPE BIGINT USING round(credit * power(10, currency_exponent(currency))),
+    ALTER COLUMN accounting_debit TYPE BIGINT
+        USING round(accounting_debit * power(10, currency_exponent(accounting_currency))),
+    ALTER COLUMN accounting_credit TYPE BIGINT
+        USING round(accounting_credit * power(10, currency_exponent(accounting_currency)));
@@ -0,0 +1,18 @@
+CREATE TABLE fees (
+    id         BIGSERIAL PRIMARY KEY,
+    client_id  BIGINT REFERENCES merchants (id),
+    service_id BIGINT REFERENCES services (id),
+    payer      VARCHAR(16)    NOT NULL CHECK (payer IN ('client', 'customer')),
+    percent    NUMERIC(20, 6) NOT NULL DEFAULT 0,
+    fixed      BIGINT         NOT NULL DEFAULT 0,
+    min_amount BIGINT         NOT NULL DEFAULT 0,
+    max_amount BIGINT         NOT NULL DEFAULT 0,
+    tiers      JSONB,
+    rounding   VARCHAR(16)    NOT NULL DEFAULT 'half_up' CHECK (rounding IN ('half_up', 'up', 'down')),
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX fees_payer_client_service_idx ON fees (payer, COALESCE(client_id, 0), COALESCE(service_id, 0))
+    WHERE deleted_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE merchants ADD COLUMN rate_markup NUMERIC(20, 6) NOT NULL DEFAULT 0;
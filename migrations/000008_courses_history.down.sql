@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN course_snapshots;
+
+DROP INDEX IF EXISTS courses_from_to_date_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX courses_from_to_date_idx ON courses ("from", "to", date DESC) WHERE deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN course_snapshots JSONB;
@@ -0,0 +1 @@
+ALTER TABLE services DROP COLUMN account_checksum, DROP COLUMN account_pre_check;
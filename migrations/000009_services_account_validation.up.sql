@@ -0,0 +1,3 @@
+ALTER TABLE services
+    ADD COLUMN account_checksum  VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN account_pre_check BOOLEAN     NOT NULL DEFAULT FALSE;
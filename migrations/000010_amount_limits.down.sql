@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS transactions_service_account_created_at_idx;
+DROP TABLE IF EXISTS client_limits;
+
+ALTER TABLE services DROP COLUMN daily_limit, DROP COLUMN monthly_limit;
@@ -0,0 +1,19 @@
+ALTER TABLE services
+    ADD COLUMN daily_limit   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN monthly_limit BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE client_limits (
+    id            BIGSERIAL PRIMARY KEY,
+    client_id     BIGINT      NOT NULL REFERENCES merchants (id),
+    service_id    BIGINT      NOT NULL REFERENCES services (id),
+    min_amount    BIGINT      NOT NULL DEFAULT 0,
+    max_amount    BIGINT      NOT NULL DEFAULT 0,
+    daily_limit   BIGINT      NOT NULL DEFAULT 0,
+    monthly_limit BIGINT      NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at    TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX client_limits_client_service_idx ON client_limits (client_id, service_id) WHERE deleted_at IS NULL;
+CREATE INDEX transactions_service_account_created_at_idx ON transactions (service_id, account, created_at);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS courses_cache_invalidation ON courses;
+DROP TRIGGER IF EXISTS providers_cache_invalidation ON providers;
+DROP TRIGGER IF EXISTS services_cache_invalidation ON services;
+DROP TRIGGER IF EXISTS merchants_cache_invalidation_delete ON merchants;
+DROP TRIGGER IF EXISTS merchants_cache_invalidation_update ON merchants;
+
+DROP FUNCTION IF EXISTS notify_cache_invalidation();
@@ -0,0 +1,39 @@
+CREATE FUNCTION notify_cache_invalidation() RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+    payload JSON;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'courses' THEN
+        payload := json_build_object('table', TG_TABLE_NAME, 'from', changed."from", 'to', changed."to");
+    ELSE
+        payload := json_build_object('table', TG_TABLE_NAME, 'id', changed.id, 'uuid', changed.uuid);
+    END IF;
+
+    PERFORM pg_notify('cache_invalidation', payload::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- balance of client is changed by each transaction and it's always read from database, so balance changes
+-- don't invalidate cached clients
+CREATE TRIGGER merchants_cache_invalidation_update AFTER UPDATE ON merchants FOR EACH ROW
+    WHEN ((OLD.uuid, OLD.name, OLD.secret_key, OLD.fee_percent, OLD.currency, OLD.rate_markup, OLD.callback_url,
+           OLD.deleted_at) IS DISTINCT FROM
+          (NEW.uuid, NEW.name, NEW.secret_key, NEW.fee_percent, NEW.currency, NEW.rate_markup, NEW.callback_url,
+           NEW.deleted_at))
+    EXECUTE PROCEDURE notify_cache_invalidation();
+CREATE TRIGGER merchants_cache_invalidation_delete AFTER DELETE ON merchants FOR EACH ROW
+    EXECUTE PROCEDURE notify_cache_invalidation();
+
+CREATE TRIGGER services_cache_invalidation AFTER UPDATE OR DELETE ON services FOR EACH ROW
+    EXECUTE PROCEDURE notify_cache_invalidation();
+CREATE TRIGGER providers_cache_invalidation AFTER UPDATE OR DELETE ON providers FOR EACH ROW
+    EXECUTE PROCEDURE notify_cache_invalidation();
+CREATE TRIGGER courses_cache_invalidation AFTER INSERT OR UPDATE OR DELETE ON courses FOR EACH ROW
+    EXECUTE PROCEDURE notify_cache_invalidation();
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS request_nonces (
+    client_id BIGINT      NOT NULL REFERENCES merchants (id),
+    nonce     VARCHAR(64) NOT NULL,
+    expire_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (client_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS request_nonces_expire_at_idx ON request_nonces (expire_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS currencies (
+    code     CHAR(3)  PRIMARY KEY,
+    exponent SMALLINT NOT NULL
+);
+
+INSERT INTO currencies (code, exponent)
+VALUES ('BIF', 0), ('CLP', 0), ('DJF', 0), ('GNF', 0), ('ISK', 0), ('JPY', 0), ('KMF', 0), ('KRW', 0), ('PYG', 0),
+       ('RWF', 0), ('UGX', 0), ('UYI', 0), ('VND', 0), ('VUV', 0), ('XAF', 0), ('XOF', 0), ('XPF', 0),
+       ('BHD', 3), ('IQD', 3), ('JOD', 3), ('KWD', 3), ('LYD', 3), ('OMR', 3), ('TND', 3)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION currency_exponent(code CHAR(3)) RETURNS INTEGER AS $$
+    SELECT COALESCE((SELECT c.exponent FROM currencies c WHERE c.code = upper($1)), 2)
+$$ LANGUAGE SQL STABLE;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS courses_from_to_date_uniq;
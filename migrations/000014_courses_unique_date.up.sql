@@ -0,0 +1,15 @@
+-- only the latest of duplicated courses of currencies pair at the same date stays effective
+UPDATE courses c
+SET deleted_at = now()
+WHERE c.deleted_at IS NULL
+  AND EXISTS(
+    SELECT 1
+    FROM courses d
+    WHERE d."from" = c."from"
+      AND d."to" = c."to"
+      AND d.date = c.date
+      AND d.deleted_at IS NULL
+      AND d.id > c.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS courses_from_to_date_uniq ON courses ("from", "to", date) WHERE deleted_at IS NULL;
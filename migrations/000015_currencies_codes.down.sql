@@ -0,0 +1 @@
+DELETE FROM currencies WHERE exponent IN (2, 4);
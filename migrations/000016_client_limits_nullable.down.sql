@@ -0,0 +1,15 @@
+UPDATE client_limits
+SET min_amount    = COALESCE(min_amount, 0),
+    max_amount    = COALESCE(max_amount, 0),
+    daily_limit   = COALESCE(daily_limit, 0),
+    monthly_limit = COALESCE(monthly_limit, 0);
+
+ALTER TABLE client_limits
+    ALTER COLUMN min_amount SET DEFAULT 0,
+    ALTER COLUMN min_amount SET NOT NULL,
+    ALTER COLUMN max_amount SET DEFAULT 0,
+    ALTER COLUMN max_amount SET NOT NULL,
+    ALTER COLUMN daily_limit SET DEFAULT 0,
+    ALTER COLUMN daily_limit SET NOT NULL,
+    ALTER COLUMN monthly_limit SET DEFAULT 0,
+    ALTER COLUMN monthly_limit SET NOT NULL;
@@ -0,0 +1,16 @@
+-- limit of client which is null isn't overridden and service limit is used, zero limit removes service limit
+ALTER TABLE client_limits
+    ALTER COLUMN min_amount DROP NOT NULL,
+    ALTER COLUMN min_amount DROP DEFAULT,
+    ALTER COLUMN max_amount DROP NOT NULL,
+    ALTER COLUMN max_amount DROP DEFAULT,
+    ALTER COLUMN daily_limit DROP NOT NULL,
+    ALTER COLUMN daily_limit DROP DEFAULT,
+    ALTER COLUMN monthly_limit DROP NOT NULL,
+    ALTER COLUMN monthly_limit DROP DEFAULT;
+
+UPDATE client_limits
+SET min_amount    = NULLIF(min_amount, 0),
+    max_amount    = NULLIF(max_amount, 0),
+    daily_limit   = NULLIF(daily_limit, 0),
+    monthly_limit = NULLIF(monthly_limit, 0);
@@ -1,5 +1,7 @@
 package pkg
 
+import "time"
+
 const (
 	RateMultiplier   = 1000000
 	AmountMultiplier = 100
@@ -18,13 +20,44 @@ type PaymentRequest struct {
 	BaseRequest
 	StatusRequest
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
-	Amount   float32                `json:"amount" validate:"required,gt=0"`
+	Amount   Decimal                `json:"amount" validate:"required"`
+}
+
+type TransactionResponse struct {
+	Id           string    `json:"id"`
+	OrderId      string    `json:"order_id"`
+	Account      string    `json:"account"`
+	Amount       Decimal   `json:"amount"`
+	Currency     string    `json:"currency"`
+	Status       string    `json:"status"`
+	RejectReason string    `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AccountCheckResponse struct {
+	Account string            `json:"account"`
+	Valid   bool              `json:"valid"`
+	Details map[string]string `json:"details,omitempty"`
 }
 
 type Error struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+	// The allowed payment amounts, it's set for errors of amount limits
+	Limits *AmountLimits `json:"limits,omitempty"`
+}
+
+// AmountLimits contains range of allowed payment amount in provider's currency and in client's currency
+type AmountLimits struct {
+	Provider *AmountRange `json:"provider"`
+	Client   *AmountRange `json:"client"`
+}
+
+type AmountRange struct {
+	Min      Decimal `json:"min"`
+	Max      Decimal `json:"max,omitempty"`
+	Currency string  `json:"currency"`
 }
 
 func NewError(code, msg string) *Error {
@@ -36,6 +69,17 @@ func (m *Error) SetDetails(details string) *Error {
 		Code:    m.Code,
 		Message: m.Message,
 		Details: details,
+		Limits:  m.Limits,
+	}
+	return err
+}
+
+func (m *Error) SetLimits(limits *AmountLimits) *Error {
+	err := &Error{
+		Code:    m.Code,
+		Message: m.Message,
+		Details: m.Details,
+		Limits:  limits,
 	}
 	return err
 }
@@ -58,4 +102,24 @@ var (
 	ErrorProviderInactive = NewError("mr000008", "provider for project with received identifier is inactive")
 	ErrorCourseNotFound   = NewError("mr000008", "rate for currency conversion from client balance currency to project recipient currency not found")
 	ErrorUnknown          = NewError("mr000008", "unknown error, try request later")
+
+	ErrorTransactionNotFound            = NewError("mr000009", "transaction with specified identifier not found")
+	ErrorTransactionAlreadyFinished     = NewError("mr000010", "transaction with specified identifier already finished")
+	ErrorGatewayNotFound                = NewError("mr000011", "gateway for provider handler not found")
+	ErrorCallbackInvalid                = NewError("mr000012", "callback payload can't be parsed")
+	ErrorCallbackSignatureInvalid       = NewError("mr000013", "callback signature is invalid")
+	ErrorUnauthorized                   = NewError("mr000014", "client authentication headers are missing")
+	ErrorRequestInvalid                 = NewError("mr000015", "request is invalid")
+	ErrorAccountInvalid                 = NewError("mr000016", "account is invalid")
+	ErrorRequestSignatureInvalid        = NewError("mr000017", "request signature is invalid")
+	ErrorRequestTimestampInvalid        = NewError("mr000018", "request timestamp is invalid or outside allowed clock skew")
+	ErrorRequestNonceReused             = NewError("mr000019", "request nonce already used")
+	ErrorTransactionConflict            = NewError("mr000020", "transaction with specified order identifier already created by other request")
+	ErrorTransactionTransitionForbidden = NewError("mr000021", "transaction status can't be changed to requested status")
+	ErrorTransactionVersionConflict     = NewError("mr000022", "transaction was changed concurrently, try request later")
+	ErrorInsufficientFunds              = NewError("mr000023", "client balance is insufficient to pay transaction amount and fee")
+	ErrorAmountInvalid                  = NewError("mr000024", "amount must be positive and not exceed minor units precision of currency")
+	ErrorAdminUnauthorized              = NewError("mr000025", "admin token is missing or invalid")
+	ErrorAmountOutOfLimits              = NewError("mr000026", "amount is out of allowed range for service")
+	ErrorAmountVelocityExceeded         = NewError("mr000027", "daily or monthly payments amount limit of account exceeded")
 )
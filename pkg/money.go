@@ -0,0 +1,482 @@
+package pkg
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Default count of digits after decimal separator in ISO 4217 currency
+	defaultCurrencyExponent = 2
+	// Count of digits after decimal separator in rate value
+	rateExponent = 6
+
+	RoundHalfUp = "half_up"
+	RoundUp     = "up"
+	RoundDown   = "down"
+)
+
+var (
+	// Exponents of known ISO 4217 currencies, they're loaded from database table currencies which is the only
+	// source of currencies exponents
+	currencyExponents   = map[string]int{}
+	currencyExponentsMx sync.RWMutex
+
+	decimalRegexp = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+	ErrorDecimalInvalid      = errors.New("decimal value is invalid")
+	ErrorDecimalPrecision    = errors.New("decimal value has more fractional digits than allowed")
+	ErrorRateInvalid         = errors.New("rate value must be positive")
+	ErrorMoneyCurrencyDiffer = errors.New("money currencies are different")
+	ErrorAmountOverflow      = errors.New("amount value is out of range")
+)
+
+// SetCurrencyExponents replaces known currencies and their exponents
+func SetCurrencyExponents(exponents map[string]int) {
+	normalized := make(map[string]int, len(exponents))
+
+	for currency, exp := range exponents {
+		normalized[strings.ToUpper(currency)] = exp
+	}
+
+	currencyExponentsMx.Lock()
+	currencyExponents = normalized
+	currencyExponentsMx.Unlock()
+}
+
+// CurrencyExponent returns count of minor units digits of ISO 4217 currency
+func CurrencyExponent(currency string) int {
+	currencyExponentsMx.RLock()
+	exp, ok := currencyExponents[strings.ToUpper(currency)]
+	currencyExponentsMx.RUnlock()
+
+	if ok {
+		return exp
+	}
+
+	return defaultCurrencyExponent
+}
+
+// IsKnownCurrency returns true if ISO 4217 currency is in list of loaded currencies
+func IsKnownCurrency(currency string) bool {
+	currencyExponentsMx.RLock()
+	_, ok := currencyExponents[strings.ToUpper(currency)]
+	currencyExponentsMx.RUnlock()
+
+	return ok
+}
+
+// Amount is a money amount in minor units of currency, i.e. cents for USD
+type Amount int64
+
+// ParseAmount parses decimal amount in major units of currency into minor units
+func ParseAmount(value, currency string) (Amount, error) {
+	unscaled, err := parseDecimal(value, CurrencyExponent(currency))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return Amount(unscaled), nil
+}
+
+// Format returns amount in major units of currency as decimal string
+func (m Amount) Format(currency string) string {
+	return formatDecimal(int64(m), CurrencyExponent(currency))
+}
+
+// Decimal returns amount in major units of currency as decimal value for API responses
+func (m Amount) Decimal(currency string) Decimal {
+	return Decimal(m.Format(currency))
+}
+
+// Money is an amount in minor units of ISO 4217 currency
+type Money struct {
+	Amount   Amount
+	Currency string
+}
+
+func NewMoney(amount Amount, currency string) *Money {
+	money := &Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+	return money
+}
+
+func (m *Money) Add(money *Money) (*Money, error) {
+	if m.Currency != money.Currency {
+		return nil, ErrorMoneyCurrencyDiffer
+	}
+
+	sum := m.Amount + money.Amount
+
+	if (sum > m.Amount) != (money.Amount > 0) {
+		return nil, ErrorAmountOverflow
+	}
+
+	return NewMoney(sum, m.Currency), nil
+}
+
+func (m *Money) Sub(money *Money) (*Money, error) {
+	if m.Currency != money.Currency {
+		return nil, ErrorMoneyCurrencyDiffer
+	}
+
+	diff := m.Amount - money.Amount
+
+	if (diff < m.Amount) != (money.Amount > 0) {
+		return nil, ErrorAmountOverflow
+	}
+
+	return NewMoney(diff, m.Currency), nil
+}
+
+// Convert converts money to currency by rate with rounding half away from zero
+func (m *Money) Convert(rate Rate, currency string) (*Money, error) {
+	amount, err := rate.Convert(m.Amount, m.Currency, currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+func (m *Money) Decimal() Decimal {
+	return m.Amount.Decimal(m.Currency)
+}
+
+func (m *Money) String() string {
+	return m.Amount.Format(m.Currency) + " " + m.Currency
+}
+
+// Rate is a currency conversion rate with fixed precision, its value is multiplied by RateMultiplier
+type Rate int64
+
+// RateOne is a rate of conversion to same currency
+const RateOne = Rate(RateMultiplier)
+
+// ParseRate parses decimal rate value
+func ParseRate(value string) (Rate, error) {
+	unscaled, err := parseDecimal(value, rateExponent)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if unscaled <= 0 {
+		return 0, ErrorRateInvalid
+	}
+
+	return Rate(unscaled), nil
+}
+
+// Convert converts amount in minor units of currency from to minor units of currency to, error is returned
+// if converted amount doesn't fit into amount range
+func (m Rate) Convert(amount Amount, from, to string) (Amount, error) {
+	num := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(m)))
+	den := big.NewInt(RateMultiplier)
+
+	if diff := CurrencyExponent(to) - CurrencyExponent(from); diff > 0 {
+		num.Mul(num, pow10(diff))
+	} else if diff < 0 {
+		den.Mul(den, pow10(-diff))
+	}
+
+	converted := divRound(num, den)
+
+	if !converted.IsInt64() {
+		return 0, ErrorAmountOverflow
+	}
+
+	return Amount(converted.Int64()), nil
+}
+
+// Inverse returns rate of conversion in opposite direction, error is returned if rate isn't positive
+// or inverse rate is rounded to zero
+func (m Rate) Inverse() (Rate, error) {
+	if m <= 0 {
+		return 0, ErrorRateInvalid
+	}
+
+	num := big.NewInt(RateMultiplier * RateMultiplier)
+	return newRate(divRound(num, big.NewInt(int64(m))))
+}
+
+// Mul returns rate of cross conversion through intermediate currency, error is returned if cross rate is rounded
+// to zero or doesn't fit into rate range
+func (m Rate) Mul(rate Rate) (Rate, error) {
+	num := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(rate)))
+	return newRate(divRound(num, big.NewInt(RateMultiplier)))
+}
+
+// WithMarkup returns rate reduced by markup percent, i.e. rate 2 with markup 1% is 1.98, error is returned
+// if reduced rate isn't positive
+func (m Rate) WithMarkup(markup Percent) (Rate, error) {
+	num := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(100*RateMultiplier-int64(markup)))
+	return newRate(divRound(num, big.NewInt(100*RateMultiplier)))
+}
+
+func (m Rate) String() string {
+	return formatDecimal(int64(m), rateExponent)
+}
+
+// Value writes rate into database as decimal string to store it in numeric column without precision loss
+func (m Rate) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads rate from numeric database column, rate which isn't positive is refused
+func (m *Rate) Scan(src interface{}) error {
+	unscaled, err := scanDecimal(src, rateExponent)
+
+	if err != nil {
+		return err
+	}
+
+	if unscaled <= 0 {
+		return ErrorRateInvalid
+	}
+
+	*m = Rate(unscaled)
+	return nil
+}
+
+func (m *Rate) UnmarshalJSON(data []byte) error {
+	rate, err := ParseRate(string(bytes.Trim(data, `"`)))
+
+	if err != nil {
+		return err
+	}
+
+	*m = rate
+	return nil
+}
+
+func (m Rate) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Percent is a percent value with fixed precision, its value is multiplied by RateMultiplier
+type Percent int64
+
+// ParsePercent parses decimal percent value, i.e. "1.5" is one and half percent
+func ParsePercent(value string) (Percent, error) {
+	unscaled, err := parseDecimal(value, rateExponent)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return Percent(unscaled), nil
+}
+
+// Of returns percent of amount rounded by rounding mode, unknown mode is treated as rounding half away from zero.
+// Error is returned if percent of amount doesn't fit into amount range.
+func (m Percent) Of(amount Amount, rounding string) (Amount, error) {
+	num := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(m)))
+	value := divRoundMode(num, big.NewInt(RateMultiplier*100), rounding)
+
+	if !value.IsInt64() {
+		return 0, ErrorAmountOverflow
+	}
+
+	return Amount(value.Int64()), nil
+}
+
+func (m Percent) String() string {
+	return formatDecimal(int64(m), rateExponent)
+}
+
+func (m Percent) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Percent) Scan(src interface{}) error {
+	unscaled, err := scanDecimal(src, rateExponent)
+
+	if err != nil {
+		return err
+	}
+
+	*m = Percent(unscaled)
+	return nil
+}
+
+func (m *Percent) UnmarshalJSON(data []byte) error {
+	unscaled, err := parseDecimal(string(bytes.Trim(data, `"`)), rateExponent)
+
+	if err != nil {
+		return err
+	}
+
+	*m = Percent(unscaled)
+	return nil
+}
+
+func (m Percent) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Decimal is an exact decimal number which is read from and written to JSON without conversion to float
+type Decimal string
+
+func (m *Decimal) UnmarshalJSON(data []byte) error {
+	value := string(bytes.Trim(data, `"`))
+
+	if !decimalRegexp.MatchString(value) {
+		return ErrorDecimalInvalid
+	}
+
+	*m = Decimal(value)
+	return nil
+}
+
+func (m Decimal) MarshalJSON() ([]byte, error) {
+	if !decimalRegexp.MatchString(string(m)) {
+		return nil, ErrorDecimalInvalid
+	}
+
+	return []byte(m), nil
+}
+
+// Amount returns decimal value in minor units of currency
+func (m Decimal) Amount(currency string) (Amount, error) {
+	return ParseAmount(string(m), currency)
+}
+
+// parseDecimal parses decimal string into integer value multiplied by 10^exp,
+// value with more fractional digits than exp is refused
+func parseDecimal(value string, exp int) (int64, error) {
+	if !decimalRegexp.MatchString(value) {
+		return 0, ErrorDecimalInvalid
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	parts := strings.SplitN(value, ".", 2)
+	fraction := ""
+
+	if len(parts) == 2 {
+		fraction = strings.TrimRight(parts[1], "0")
+	}
+
+	if len(fraction) > exp {
+		return 0, ErrorDecimalPrecision
+	}
+
+	unscaled, err := strconv.ParseInt(parts[0]+fraction+strings.Repeat("0", exp-len(fraction)), 10, 64)
+
+	if err != nil {
+		return 0, ErrorDecimalInvalid
+	}
+
+	if negative {
+		unscaled = -unscaled
+	}
+
+	return unscaled, nil
+}
+
+// scanDecimal reads decimal value of numeric database column into integer value multiplied by 10^exp
+func scanDecimal(src interface{}, exp int) (int64, error) {
+	var value string
+
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	case int64:
+		value = strconv.FormatInt(v, 10)
+	case float64:
+		value = strconv.FormatFloat(v, 'f', exp, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T of decimal value", src)
+	}
+
+	return parseDecimal(value, exp)
+}
+
+func formatDecimal(unscaled int64, exp int) string {
+	sign := ""
+
+	if unscaled < 0 {
+		sign = "-"
+		unscaled = -unscaled
+	}
+
+	value := strconv.FormatInt(unscaled, 10)
+
+	if exp == 0 {
+		return sign + value
+	}
+
+	if len(value) <= exp {
+		value = strings.Repeat("0", exp-len(value)+1) + value
+	}
+
+	return sign + value[:len(value)-exp] + "." + value[len(value)-exp:]
+}
+
+// newRate returns rate by its unscaled value, error is returned if value isn't positive or doesn't fit into rate range
+func newRate(unscaled *big.Int) (Rate, error) {
+	if !unscaled.IsInt64() {
+		return 0, ErrorAmountOverflow
+	}
+
+	if unscaled.Sign() <= 0 {
+		return 0, ErrorRateInvalid
+	}
+
+	return Rate(unscaled.Int64()), nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// divRoundMode divides num by positive den with rounding by mode, rounding up and down are away from zero
+// and toward zero respectively
+func divRoundMode(num, den *big.Int, rounding string) *big.Int {
+	switch rounding {
+	case RoundDown:
+		return new(big.Int).Quo(num, den)
+	case RoundUp:
+		quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+		if rem.Sign() > 0 {
+			quo.Add(quo, big.NewInt(1))
+		} else if rem.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		}
+
+		return quo
+	}
+
+	return divRound(num, den)
+}
+
+// divRound divides num by positive den with rounding half away from zero
+func divRound(num, den *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	rem.Abs(rem).Mul(rem, big.NewInt(2))
+
+	if rem.Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	return quo
+}
@@ -0,0 +1,350 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	SetCurrencyExponents(map[string]int{"USD": 2, "EUR": 2, "JPY": 0, "kwd": 3})
+	os.Exit(m.Run())
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		amount   Amount
+		err      error
+	}{
+		{"integer", "10", "USD", 1000, nil},
+		{"fraction", "10.5", "USD", 1050, nil},
+		{"trailing zeros", "10.500", "USD", 1050, nil},
+		{"negative", "-0.01", "USD", -1, nil},
+		{"lower case currency", "10.5", "usd", 1050, nil},
+		{"zero exponent", "150", "JPY", 150, nil},
+		{"three digits exponent", "1.234", "KWD", 1234, nil},
+		{"precision exceeded", "1.001", "USD", 0, ErrorDecimalPrecision},
+		{"fraction for zero exponent", "1.5", "JPY", 0, ErrorDecimalPrecision},
+		{"not a number", "abc", "USD", 0, ErrorDecimalInvalid},
+		{"exponent notation", "1e3", "USD", 0, ErrorDecimalInvalid},
+		{"int64 overflow", "100000000000000000", "USD", 0, ErrorDecimalInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := ParseAmount(tt.value, tt.currency)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if amount != tt.amount {
+				t.Errorf("expected amount %d, got %d", tt.amount, amount)
+			}
+		})
+	}
+}
+
+func TestAmount_Format(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		currency string
+		expected string
+	}{
+		{1050, "USD", "10.50"},
+		{5, "USD", "0.05"},
+		{-5, "USD", "-0.05"},
+		{0, "USD", "0.00"},
+		{150, "JPY", "150"},
+		{1234, "KWD", "1.234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected+" "+tt.currency, func(t *testing.T) {
+			if formatted := tt.amount.Format(tt.currency); formatted != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, formatted)
+			}
+		})
+	}
+}
+
+func TestMoney_AddSub(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(a, b *Money) (*Money, error)
+		a, b *Money
+		sum  *Money
+		err  error
+	}{
+		{"add", (*Money).Add, NewMoney(150, "USD"), NewMoney(50, "USD"), NewMoney(200, "USD"), nil},
+		{"add negative", (*Money).Add, NewMoney(150, "USD"), NewMoney(-200, "USD"), NewMoney(-50, "USD"), nil},
+		{"add currencies differ", (*Money).Add, NewMoney(150, "USD"), NewMoney(50, "EUR"), nil, ErrorMoneyCurrencyDiffer},
+		{"add overflow", (*Money).Add, NewMoney(math.MaxInt64, "USD"), NewMoney(1, "USD"), nil, ErrorAmountOverflow},
+		{"add negative overflow", (*Money).Add, NewMoney(math.MinInt64, "USD"), NewMoney(-1, "USD"), nil, ErrorAmountOverflow},
+		{"sub", (*Money).Sub, NewMoney(150, "USD"), NewMoney(50, "USD"), NewMoney(100, "USD"), nil},
+		{"sub zero", (*Money).Sub, NewMoney(150, "USD"), NewMoney(0, "USD"), NewMoney(150, "USD"), nil},
+		{"sub currencies differ", (*Money).Sub, NewMoney(150, "USD"), NewMoney(50, "EUR"), nil, ErrorMoneyCurrencyDiffer},
+		{"sub overflow", (*Money).Sub, NewMoney(math.MinInt64, "USD"), NewMoney(1, "USD"), nil, ErrorAmountOverflow},
+		{"sub negative overflow", (*Money).Sub, NewMoney(math.MaxInt64, "USD"), NewMoney(-1, "USD"), nil, ErrorAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, err := tt.op(tt.a, tt.b)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if tt.sum != nil && *sum != *tt.sum {
+				t.Errorf("expected %s, got %s", tt.sum, sum)
+			}
+		})
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     string
+		amount   Amount
+		from, to string
+		expected Amount
+		err      error
+	}{
+		{"same exponents", "1.5", 1000, "USD", "EUR", 1500, nil},
+		{"to zero exponent", "150", 1000, "USD", "JPY", 1500, nil},
+		{"from zero exponent", "0.006667", 1500, "JPY", "USD", 1000, nil},
+		{"to three digits exponent", "0.3", 1000, "USD", "KWD", 3000, nil},
+		{"half rounded up", "0.5", 1, "USD", "EUR", 1, nil},
+		{"negative half rounded away from zero", "0.5", -1, "USD", "EUR", -1, nil},
+		{"less than half rounded down", "0.4", 1, "USD", "EUR", 0, nil},
+		{"overflow", "2", math.MaxInt64, "USD", "EUR", 0, ErrorAmountOverflow},
+		{"overflow by exponents", "1", math.MaxInt64 / 5, "USD", "KWD", 0, ErrorAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+
+			if err != nil {
+				t.Fatalf("rate parsing failed: %v", err)
+			}
+
+			converted, err := rate.Convert(tt.amount, tt.from, tt.to)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if converted != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, converted)
+			}
+		})
+	}
+}
+
+func TestRate_Arithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     func() (Rate, error)
+		expected string
+		err      error
+	}{
+		{"inverse", func() (Rate, error) { return mustParseRate(t, "4").Inverse() }, "0.250000", nil},
+		{"inverse rounded", func() (Rate, error) { return mustParseRate(t, "3").Inverse() }, "0.333333", nil},
+		{"inverse of zero", func() (Rate, error) { return Rate(0).Inverse() }, "", ErrorRateInvalid},
+		{"inverse rounded to zero", func() (Rate, error) { return Rate(math.MaxInt64).Inverse() }, "", ErrorRateInvalid},
+		{"mul", func() (Rate, error) { return mustParseRate(t, "1.5").Mul(mustParseRate(t, "0.5")) }, "0.750000", nil},
+		{
+			"mul overflow",
+			func() (Rate, error) { return Rate(math.MaxInt64).Mul(mustParseRate(t, "2")) },
+			"",
+			ErrorAmountOverflow,
+		},
+		{
+			"markup",
+			func() (Rate, error) { return mustParseRate(t, "2").WithMarkup(Percent(RateMultiplier)) },
+			"1.980000",
+			nil,
+		},
+		{
+			"markup of whole rate",
+			func() (Rate, error) { return mustParseRate(t, "2").WithMarkup(Percent(100 * RateMultiplier)) },
+			"",
+			ErrorRateInvalid,
+		},
+		{
+			"negative markup overflow",
+			func() (Rate, error) { return Rate(math.MaxInt64).WithMarkup(Percent(-RateMultiplier)) },
+			"",
+			ErrorAmountOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := tt.rate()
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if err == nil && rate.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, rate)
+			}
+		})
+	}
+}
+
+func TestRate_Scan(t *testing.T) {
+	tests := []struct {
+		src      interface{}
+		expected Rate
+		err      error
+	}{
+		{"1.5", Rate(1500000), nil},
+		{[]byte("0.000001"), Rate(1), nil},
+		{"0", 0, ErrorRateInvalid},
+		{"-1", 0, ErrorRateInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s", tt.src), func(t *testing.T) {
+			var rate Rate
+
+			if err := rate.Scan(tt.src); err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if rate != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, rate)
+			}
+		})
+	}
+}
+
+func TestPercent_Scan(t *testing.T) {
+	var percent Percent
+
+	if err := percent.Scan("0"); err != nil || percent != 0 {
+		t.Errorf("expected zero percent, got %s, %v", percent, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{"1.5", nil},
+		{"0", ErrorRateInvalid},
+		{"-1", ErrorRateInvalid},
+		{"0.0000001", ErrorDecimalPrecision},
+		{"rate", ErrorDecimalInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if _, err := ParseRate(tt.value); err != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPercent_Of(t *testing.T) {
+	tests := []struct {
+		name     string
+		percent  string
+		amount   Amount
+		rounding string
+		expected Amount
+		err      error
+	}{
+		{"exact", "1.5", 1000, RoundHalfUp, 15, nil},
+		{"half up", "1", 150, RoundHalfUp, 2, nil},
+		{"half up below half", "1", 149, RoundHalfUp, 1, nil},
+		{"up", "1", 101, RoundUp, 2, nil},
+		{"up exact", "1", 100, RoundUp, 1, nil},
+		{"down", "1", 199, RoundDown, 1, nil},
+		{"unknown mode is half up", "1", 150, "", 2, nil},
+		{"negative amount up", "1", -101, RoundUp, -2, nil},
+		{"zero percent", "0", 1000, RoundUp, 0, nil},
+		{"overflow", "200", math.MaxInt64, RoundUp, 0, ErrorAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			percent, err := ParsePercent(tt.percent)
+
+			if err != nil {
+				t.Fatalf("percent parsing failed: %v", err)
+			}
+
+			fee, err := percent.Of(tt.amount, tt.rounding)
+
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if fee != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, fee)
+			}
+		})
+	}
+}
+
+func TestCurrencyExponent(t *testing.T) {
+	tests := []struct {
+		currency string
+		expected int
+	}{
+		{"USD", 2},
+		{"JPY", 0},
+		{"jpy", 0},
+		{"KWD", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			if exp := CurrencyExponent(tt.currency); exp != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, exp)
+			}
+		})
+	}
+}
+
+func TestIsKnownCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		expected bool
+	}{
+		{"USD", true},
+		{"jpy", true},
+		{"KWD", true},
+		{"XYZ", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			if known := IsKnownCurrency(tt.currency); known != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, known)
+			}
+		})
+	}
+}
+
+func mustParseRate(t *testing.T, value string) Rate {
+	rate, err := ParseRate(value)
+
+	if err != nil {
+		t.Fatalf("rate %q parsing failed: %v", value, err)
+	}
+
+	return rate
+}